@@ -6,6 +6,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	_ "net/http/pprof"
@@ -15,7 +16,6 @@ import (
 	"net/http"
 	"os/signal"
 	"runtime"
-	"strings"
 	"syscall"
 	"time"
 
@@ -33,6 +33,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
@@ -48,33 +49,12 @@ import (
 )
 
 const (
-	environment     = "production"
-	id              = int64(1)
-	metricsEndpoint = "/metrics"
-	metricsPort     = 8080
-	grpcPort        = 6565
-)
+	id = int64(1)
 
-var (
-	serviceName = "extend-app-matchmaking-func"
-	logLevelStr = common.GetEnv("LOG_LEVEL", "info")
+	// shutdownFlushTimeout bounds the metrics server shutdown and the tracer flush once the gRPC server stopped
+	shutdownFlushTimeout = 10 * time.Second
 )
 
-func parseSlogLevel(levelStr string) slog.Level {
-	switch strings.ToLower(levelStr) {
-	case "debug":
-		return slog.LevelDebug
-	case "info":
-		return slog.LevelInfo
-	case "warn", "warning":
-		return slog.LevelWarn
-	case "error", "fatal", "panic":
-		return slog.LevelError
-	default:
-		return slog.LevelInfo
-	}
-}
-
 func main() {
 	go func() {
 		runtime.SetBlockProfileRate(1)
@@ -84,8 +64,17 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Parse log level from environment variable
-	slogLevel := parseSlogLevel(logLevelStr)
+	// Load the config from the defaults, the config file, the environment and the flags
+	cfg, err := common.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		slog.Error("invalid config", "error", err)
+		os.Exit(1)
+	}
+
+	slogLevel, _ := common.ParseLogLevel(cfg.LogLevel)
 
 	// Create JSON handler for structured logging
 	opts := &slog.HandlerOptions{
@@ -95,7 +84,10 @@ func main() {
 	logger := slog.New(handler)
 	slog.SetDefault(logger) // Set as default logger for the application
 
-	logger.Info("starting app server")
+	logger.Info("starting app server", "config", cfg)
+	for _, warning := range cfg.Warnings() {
+		logger.Warn("incomplete config", "warning", warning)
+	}
 
 	loggingOptions := []logging.Option{
 		logging.WithLogOnEvents(logging.StartCall, logging.FinishCall, logging.PayloadReceived, logging.PayloadSent),
@@ -122,7 +114,11 @@ func main() {
 
 	// Preparing the IAM authorization
 	var tokenRepo repository.TokenRepository = sdkAuth.DefaultTokenRepositoryImpl()
-	var configRepo repository.ConfigRepository = sdkAuth.DefaultConfigRepositoryImpl()
+	var configRepo repository.ConfigRepository = &sdkAuth.ConfigRepositoryImpl{
+		ClientId:     cfg.Auth.ClientID,
+		ClientSecret: cfg.Auth.ClientSecret,
+		BaseUrl:      cfg.Auth.BaseURL,
+	}
 	var refreshRepo repository.RefreshTokenRepository = &sdkAuth.RefreshTokenImpl{RefreshRate: 0.8, AutoRefresh: true}
 
 	oauthService := iam.OAuth20Service{
@@ -132,9 +128,14 @@ func main() {
 		ConfigRepository:       configRepo,
 	}
 
-	if strings.ToLower(common.GetEnv("PLUGIN_GRPC_SERVER_AUTH_ENABLED", "true")) == "true" {
-		refreshInterval := common.GetEnvInt("REFRESH_INTERVAL", 600)
-		common.Validator = common.NewTokenValidator(oauthService, time.Duration(refreshInterval)*time.Second, true)
+	if cfg.Auth.Enabled {
+		common.Namespace = cfg.Auth.Namespace
+		common.RequiredPermissions = cfg.Auth.Permissions
+		if cfg.Auth.Validator == common.AuthValidatorOffline {
+			common.Validator = common.NewOfflineTokenValidator(cfg.Auth.KeysPath, cfg.Auth.RevocationListPath, time.Duration(cfg.Auth.RefreshInterval))
+		} else {
+			common.Validator = common.NewTokenValidator(oauthService, time.Duration(cfg.Auth.RefreshInterval), true)
+		}
 		err := common.Validator.Initialize(ctx)
 		if err != nil {
 			logger.Info("initialization error", "error", err)
@@ -145,25 +146,47 @@ func main() {
 		logger.Info("added auth interceptors")
 	}
 
-	// Create gRPC Server
-	grpcServer := grpc.NewServer(
+	serverOptions := []grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(unaryServerInterceptors...),
 		grpc.ChainStreamInterceptor(streamServerInterceptors...),
-	)
+	}
+
+	// Serve TLS, and verify the client certificates for mutual TLS, when a certificate is configured
+	if cfg.TLS.Enabled() {
+		tlsConfig, err := common.NewServerTLSConfig(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile)
+		if err != nil {
+			logger.Error("failed to load TLS certificate", "error", err)
+			os.Exit(1)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		logger.Info("gRPC TLS enabled", "mutualTLS", cfg.TLS.ClientCAFile != "")
+	}
 
-	matchMaker := server.New()
-	matchfunctiongrpc.RegisterMatchFunctionServer(grpcServer, &server.MatchFunctionServer{
+	// Create gRPC Server
+	grpcServer := grpc.NewServer(serverOptions...)
+
+	// Every match pool uses the default MatchLogic unless another one is registered for it, for example with
+	// matchLogic.Register("ranked", rankedLogic) and matchLogic.RegisterPool("ranked-pool", "ranked")
+	matchLogic := server.NewLogicRegistry(server.New())
+	// Middlewares wrap every MatchLogic call, the first one being the outermost
+	matchLogicMetrics := server.NewMatchLogicMetrics()
+	matchFunctionServer := &server.MatchFunctionServer{
 		UnimplementedMatchFunctionServer: matchfunctiongrpc.UnimplementedMatchFunctionServer{},
-		MM:                               matchMaker,
-	})
+		MM:                               server.Chain(matchLogic, server.LoggingMiddleware(), matchLogicMetrics.Middleware()),
+	}
+	if cfg.MatchValidation.Enabled {
+		matchFunctionServer.Validator = server.NewMatchValidator(cfg.MatchValidation.Strict)
+	}
+	matchfunctiongrpc.RegisterMatchFunctionServer(grpcServer, matchFunctionServer)
 
 	// Enable gRPC Reflection
 	reflection.Register(grpcServer)
 	logger.Info("gRPC reflection enabled")
 
 	// Enable gRPC Health Check
-	grpc_health_v1.RegisterHealthServer(grpcServer, health.NewServer())
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
 
 	// Add go runtime metrics and process collectors.
 	srvMetrics.InitializeMetrics(grpcServer)
@@ -172,19 +195,24 @@ func main() {
 		collectors.NewGoCollector(),
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 		srvMetrics,
+		matchLogicMetrics,
 	)
+	if matchFunctionServer.Validator != nil {
+		promRegistry.MustRegister(matchFunctionServer.Validator)
+	}
 
+	http.Handle(cfg.MetricsEndpoint, promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{}))
+	metricsServer := &http.Server{Addr: fmt.Sprintf(":%d", cfg.MetricsPort)}
 	go func() {
-		http.Handle(metricsEndpoint, promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{}))
-		if err := http.ListenAndServe(fmt.Sprintf(":%d", metricsPort), nil); err != nil {
+		if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Error("failed to serve metrics", "error", err)
 			os.Exit(1)
 		}
 	}()
-	logger.Info("prometheus metrics served at :8080/metrics")
+	logger.Info("prometheus metrics served", "address", metricsServer.Addr, "endpoint", cfg.MetricsEndpoint)
 
 	logger.Info("listening to grpc port")
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
 	if err != nil {
 		logger.Error("failed to listen", "error", err)
 		os.Exit(1)
@@ -196,7 +224,7 @@ func main() {
 
 	logger.Info("listening...")
 	go func() {
-		if err = grpcServer.Serve(lis); err != nil {
+		if err := grpcServer.Serve(lis); err != nil {
 			logger.Error("failed to serve", "error", err)
 			os.Exit(1)
 
@@ -207,10 +235,7 @@ func main() {
 	logger.Info("starting init provider")
 
 	// Save Tracer Provider
-	if val := common.GetEnv("OTEL_SERVICE_NAME", ""); val != "" {
-		serviceName = "extend-app-mm-" + strings.ToLower(val)
-	}
-	tracerProvider, err := common.NewTracerProvider(serviceName, environment, id)
+	tracerProvider, err := common.NewTracerProvider(cfg.ServiceName, cfg.Environment, id, cfg.ZipkinEndpoint)
 	if err != nil {
 		logger.Error("failed to create tracer provider", "error", err)
 		os.Exit(1)
@@ -229,18 +254,43 @@ func main() {
 		propagation.Baggage{},
 	))
 
-	// Cleanly shutdown and flush telemetry when the application exits.
-	defer func(ctx context.Context) {
-		if err := tracerProvider.Shutdown(ctx); err != nil {
-			logger.Error("failed to shutdown tracer provider", "error", err)
-			os.Exit(1)
-		}
-	}(ctx)
-
-	flag.Parse()
+	shutdownGracePeriod := time.Duration(cfg.ShutdownGracePeriod)
 
-	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
-	<-ctx.Done()
-	logger.Info("signal received")
+	<-signalCtx.Done()
+	logger.Info("signal received, shutting down", "gracePeriod", shutdownGracePeriod.String())
+
+	// Report NOT_SERVING first so the health checks take this instance out of rotation
+	healthServer.Shutdown()
+
+	// Stop accepting new streams and let the in-flight ones flush their matches, up to the grace period
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		logger.Info("gRPC server stopped")
+	case <-time.After(shutdownGracePeriod):
+		logger.Warn("grace period elapsed, forcing gRPC server stop")
+		grpcServer.Stop()
+		<-stopped
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	defer shutdownCancel()
+
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed to shutdown metrics server", "error", err)
+	}
+
+	// Flush the remaining spans
+	if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed to shutdown tracer provider", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("app server stopped")
 }
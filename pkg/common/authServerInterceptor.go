@@ -8,7 +8,10 @@ import (
 	"context"
 	"crypto/rsa"
 	"encoding/base64"
-	"os"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
 	"strings"
 	"time"
 
@@ -24,13 +27,41 @@ import (
 
 var Validator validator.AuthTokenValidator
 
+// Namespace is the AGS namespace the access tokens are validated against
+var Namespace string
+
+// RequiredPermissions maps a gRPC method name, such as "MakeMatches", to the permission the access token must grant
+// to call it. Methods without an entry only require a valid token.
+var RequiredPermissions map[string]Permission
+
+type claimsContextKey struct{}
+
+// ClaimsFromContext returns the claims of the access token the call was authorized with
+func ClaimsFromContext(ctx context.Context) (*JWTClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*JWTClaims)
+
+	return claims, ok
+}
+
+// authServerStream carries the access token claims in the stream context
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
+
 func UnaryAuthServerIntercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	if !skipCheckAuthorizationMetadata(info.FullMethod) {
-		err := checkAuthorizationMetadata(ctx)
+		claims, err := checkAuthorizationMetadata(ctx, info.FullMethod)
 
 		if err != nil {
 			return nil, err
 		}
+
+		ctx = context.WithValue(ctx, claimsContextKey{}, claims)
 	}
 
 	return handler(ctx, req)
@@ -38,11 +69,13 @@ func UnaryAuthServerIntercept(ctx context.Context, req interface{}, info *grpc.U
 
 func StreamAuthServerIntercept(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 	if !skipCheckAuthorizationMetadata(info.FullMethod) {
-		err := checkAuthorizationMetadata(ss.Context())
+		claims, err := checkAuthorizationMetadata(ss.Context(), info.FullMethod)
 
 		if err != nil {
 			return err
 		}
+
+		ss = &authServerStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), claimsContextKey{}, claims)}
 	}
 
 	return handler(srv, ss)
@@ -60,36 +93,66 @@ func skipCheckAuthorizationMetadata(fullMethod string) bool {
 	return false
 }
 
-func checkAuthorizationMetadata(ctx context.Context) error {
+func checkAuthorizationMetadata(ctx context.Context, fullMethod string) (*JWTClaims, error) {
 	if Validator == nil {
-		return status.Error(codes.Internal, "authorization token validator is not set")
+		return nil, status.Error(codes.Internal, "authorization token validator is not set")
 	}
 
 	meta, found := metadata.FromIncomingContext(ctx)
 
 	if !found {
-		return status.Error(codes.Unauthenticated, "metadata is missing")
+		return nil, status.Error(codes.Unauthenticated, "metadata is missing")
 	}
 
 	if _, ok := meta["authorization"]; !ok {
-		return status.Error(codes.Unauthenticated, "authorization metadata is missing")
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata is missing")
 	}
 
 	if len(meta["authorization"]) == 0 {
-		return status.Error(codes.Unauthenticated, "authorization metadata length is 0")
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata length is 0")
 	}
 
 	authorization := meta["authorization"][0]
 	token := strings.TrimPrefix(authorization, "Bearer ")
-	namespace := os.Getenv("AB_NAMESPACE")
+	namespace := Namespace
+
+	var permission *iam.Permission
+	if required, ok := RequiredPermissions[path.Base(fullMethod)]; ok {
+		permission = &iam.Permission{Resource: required.Resource, Action: required.Action}
+	}
+
+	err := Validator.Validate(token, permission, &namespace, nil)
 
-	err := Validator.Validate(token, nil, &namespace, nil)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
 
+	// the token signature was verified by the validator, so the claims can be read from the payload as they are
+	claims, err := decodeClaims(token)
 	if err != nil {
-		return status.Error(codes.PermissionDenied, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return claims, nil
+}
+
+func decodeClaims(token string) (*JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("access token is not a signed JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not decode access token payload: %w", err)
+	}
+
+	var claims JWTClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("could not decode access token claims: %w", err)
 	}
 
-	return nil
+	return &claims, nil
 }
 
 func NewTokenValidator(authService iam.OAuth20Service, refreshInterval time.Duration, validateLocally bool) validator.AuthTokenValidator {
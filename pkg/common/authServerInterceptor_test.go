@@ -0,0 +1,127 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeValidator accepts every token unless it has an error, and records the permission it was asked to check
+type fakeValidator struct {
+	err        error
+	permission *iam.Permission
+}
+
+func (v *fakeValidator) Initialize(...context.Context) error {
+	return nil
+}
+
+func (v *fakeValidator) Validate(_ string, permission *iam.Permission, _ *string, _ *string) error {
+	v.permission = permission
+
+	return v.err
+}
+
+// testToken returns an unsigned access token carrying the claims, for validators that do not check signatures
+func testToken(t *testing.T, claims JWTClaims) string {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".c2ln"
+}
+
+func TestUnaryAuthServerInterceptClaims(t *testing.T) {
+	makeMatches := Permission{Resource: "NAMESPACE:namespace:MATCHMAKING", Action: 2}
+	claims := JWTClaims{Namespace: "namespace", ClientID: "client", Permissions: []Permission{makeMatches}}
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string // no authorization metadata when empty
+		err        error
+		code       codes.Code
+		permission *Permission
+		claims     bool
+	}{
+		{name: "claims on the scope", method: "/service.MatchFunction/GetStatCodes", token: testToken(t, claims), claims: true},
+		{
+			name:       "required permission checked",
+			method:     "/service.MatchFunction/MakeMatches",
+			token:      testToken(t, claims),
+			permission: &makeMatches,
+			claims:     true,
+		},
+		{
+			name:       "permission denied",
+			method:     "/service.MatchFunction/MakeMatches",
+			token:      testToken(t, claims),
+			err:        errors.New("insufficient permission"),
+			code:       codes.PermissionDenied,
+			permission: &makeMatches,
+		},
+		{name: "no token", method: "/service.MatchFunction/GetStatCodes", code: codes.Unauthenticated},
+		{name: "token not a jwt", method: "/service.MatchFunction/GetStatCodes", token: "token", code: codes.Unauthenticated},
+		{name: "health check skipped", method: "/grpc.health.v1.Health/Check"},
+	}
+
+	savedValidator, savedNamespace, savedPermissions := Validator, Namespace, RequiredPermissions
+	defer func() {
+		Validator, Namespace, RequiredPermissions = savedValidator, savedNamespace, savedPermissions
+	}()
+	Namespace = "namespace"
+	RequiredPermissions = map[string]Permission{"MakeMatches": makeMatches}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &fakeValidator{err: tt.err}
+			Validator = validator
+
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tt.token))
+			}
+
+			var scopeClaims *JWTClaims
+			var found bool
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				scope := ChildScopeFromRemoteScope(ctx, "test")
+				defer scope.Finish()
+				scopeClaims, found = scope.Claims()
+
+				return nil, nil
+			}
+
+			_, err := UnaryAuthServerIntercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected the code %v, got %v", tt.code, err)
+			}
+
+			if (validator.permission != nil) != (tt.permission != nil) {
+				t.Errorf("expected the permission %v to be checked, got %v", tt.permission, validator.permission)
+			} else if tt.permission != nil && (validator.permission.Resource != tt.permission.Resource || validator.permission.Action != tt.permission.Action) {
+				t.Errorf("expected the permission %v to be checked, got %v", tt.permission, validator.permission)
+			}
+
+			if found != tt.claims {
+				t.Fatalf("expected claims on the scope %v, got %v", tt.claims, found)
+			}
+			if found && (scopeClaims.ClientID != "client" || len(scopeClaims.Permissions) != 1 || scopeClaims.Permissions[0].Resource != makeMatches.Resource) {
+				t.Errorf("expected the claims of the token on the scope, got %+v", scopeClaims)
+			}
+		})
+	}
+}
@@ -0,0 +1,546 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	matchfunctiongrpc "matchmaking-function-grpc-plugin-server-go/pkg/pb"
+)
+
+const (
+	configFileEnv  = "CONFIG_FILE"
+	configFileFlag = "config"
+
+	redactedValue = "*****"
+
+	// AuthValidatorIAM validates the access tokens with the AGS IAM service
+	AuthValidatorIAM = "iam"
+	// AuthValidatorOffline validates the access tokens against local public keys, see OfflineTokenValidator
+	AuthValidatorOffline = "offline"
+
+	permissionActionCreate = 1
+	permissionActionAll    = 15 // CREATE | READ | UPDATE | DELETE
+)
+
+// Config holds the server settings. They are loaded by LoadConfig, where the config file overrides the defaults,
+// the environment variables override the config file and the command-line flags override the environment variables.
+type Config struct {
+	GRPCPort            int      `yaml:"grpc_port" json:"grpc_port"`
+	MetricsPort         int      `yaml:"metrics_port" json:"metrics_port"`
+	MetricsEndpoint     string   `yaml:"metrics_endpoint" json:"metrics_endpoint"`
+	ServiceName         string   `yaml:"service_name" json:"service_name"`
+	Environment         string   `yaml:"environment" json:"environment"`
+	LogLevel            string   `yaml:"log_level" json:"log_level"`
+	ZipkinEndpoint      string   `yaml:"zipkin_endpoint" json:"zipkin_endpoint"`
+	ShutdownGracePeriod Duration `yaml:"shutdown_grace_period" json:"shutdown_grace_period"`
+
+	Auth            AuthConfig            `yaml:"auth" json:"auth"`
+	TLS             TLSConfig             `yaml:"tls" json:"tls"`
+	MatchValidation MatchValidationConfig `yaml:"match_validation" json:"match_validation"`
+}
+
+// MatchValidationConfig holds the settings of the checks run on the matches before they are sent. Invalid matches
+// are logged and counted, and are only dropped in strict mode.
+type MatchValidationConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	Strict  bool `yaml:"strict" json:"strict"`
+}
+
+// TLSConfig holds the TLS settings of the gRPC listener. TLS is enabled when a certificate is set, and client
+// certificates are required and verified when a client CA bundle is set.
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file" json:"cert_file"`
+	KeyFile      string `yaml:"key_file" json:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file" json:"client_ca_file"`
+}
+
+// Enabled reports whether the gRPC listener serves TLS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// AuthConfig holds the AGS IAM settings used to validate the access token of the gRPC calls
+type AuthConfig struct {
+	Enabled         bool     `yaml:"enabled" json:"enabled"`
+	Validator       string   `yaml:"validator" json:"validator"`
+	RefreshInterval Duration `yaml:"refresh_interval" json:"refresh_interval"`
+	BaseURL         string   `yaml:"base_url" json:"base_url"`
+	ClientID        string   `yaml:"client_id" json:"client_id"`
+	ClientSecret    string   `yaml:"client_secret" json:"client_secret"`
+	Namespace       string   `yaml:"namespace" json:"namespace"`
+
+	// KeysPath is the JWKS file or the directory of PEM public keys of the offline validator
+	KeysPath string `yaml:"keys_path" json:"keys_path"`
+	// RevocationListPath is the optional revocation list file of the offline validator
+	RevocationListPath string `yaml:"revocation_list_path" json:"revocation_list_path"`
+
+	// Permissions maps a MatchFunction method name, such as "MakeMatches", to the permission its caller needs.
+	// It can only be set in the config file.
+	Permissions map[string]Permission `yaml:"permissions" json:"permissions"`
+}
+
+// DefaultConfig returns the settings used when neither the config file, the environment nor the flags set them
+func DefaultConfig() Config {
+	return Config{
+		GRPCPort:            6565,
+		MetricsPort:         8080,
+		MetricsEndpoint:     "/metrics",
+		ServiceName:         "extend-app-matchmaking-func",
+		Environment:         "production",
+		LogLevel:            "info",
+		ZipkinEndpoint:      "http://localhost:9411/api/v2/spans",
+		ShutdownGracePeriod: Duration(30 * time.Second),
+		Auth: AuthConfig{
+			Enabled:         true,
+			Validator:       AuthValidatorIAM,
+			RefreshInterval: Duration(600 * time.Second),
+		},
+		MatchValidation: MatchValidationConfig{
+			Enabled: true,
+		},
+	}
+}
+
+// setting names a config value in every source it can be set from
+type setting struct {
+	key  string // key in the config file
+	env  string
+	flag string
+}
+
+func (s setting) String() string {
+	return fmt.Sprintf("%s (env %s, flag -%s)", s.key, s.env, s.flag)
+}
+
+var (
+	grpcPortSetting            = setting{"grpc_port", "GRPC_PORT", "grpc-port"}
+	metricsPortSetting         = setting{"metrics_port", "METRICS_PORT", "metrics-port"}
+	metricsEndpointSetting     = setting{"metrics_endpoint", "METRICS_ENDPOINT", "metrics-endpoint"}
+	serviceNameSetting         = setting{"service_name", "OTEL_SERVICE_NAME", "service-name"}
+	environmentSetting         = setting{"environment", "ENVIRONMENT", "environment"}
+	logLevelSetting            = setting{"log_level", "LOG_LEVEL", "log-level"}
+	zipkinEndpointSetting      = setting{"zipkin_endpoint", "OTEL_EXPORTER_ZIPKIN_ENDPOINT", "zipkin-endpoint"}
+	shutdownGracePeriodSetting = setting{"shutdown_grace_period", "SHUTDOWN_GRACE_PERIOD", "shutdown-grace-period"}
+	authEnabledSetting         = setting{"auth.enabled", "PLUGIN_GRPC_SERVER_AUTH_ENABLED", "auth-enabled"}
+	authValidatorSetting       = setting{"auth.validator", "PLUGIN_GRPC_SERVER_AUTH_VALIDATOR", "auth-validator"}
+	keysPathSetting            = setting{"auth.keys_path", "PLUGIN_GRPC_SERVER_AUTH_KEYS_PATH", "auth-keys-path"}
+	revocationListPathSetting  = setting{"auth.revocation_list_path", "PLUGIN_GRPC_SERVER_AUTH_REVOCATION_LIST_PATH", "auth-revocation-list-path"}
+	refreshIntervalSetting     = setting{"auth.refresh_interval", "REFRESH_INTERVAL", "auth-refresh-interval"}
+	baseURLSetting             = setting{"auth.base_url", "AB_BASE_URL", "ab-base-url"}
+	clientIDSetting            = setting{"auth.client_id", "AB_CLIENT_ID", "ab-client-id"}
+	clientSecretSetting        = setting{"auth.client_secret", "AB_CLIENT_SECRET", "ab-client-secret"}
+	namespaceSetting           = setting{"auth.namespace", "AB_NAMESPACE", "ab-namespace"}
+	tlsCertFileSetting         = setting{"tls.cert_file", "PLUGIN_GRPC_SERVER_TLS_CERT_FILE", "tls-cert-file"}
+	tlsKeyFileSetting          = setting{"tls.key_file", "PLUGIN_GRPC_SERVER_TLS_KEY_FILE", "tls-key-file"}
+	tlsClientCAFileSetting     = setting{"tls.client_ca_file", "PLUGIN_GRPC_SERVER_TLS_CLIENT_CA_FILE", "tls-client-ca-file"}
+	matchValidationSetting     = setting{"match_validation.enabled", "MATCH_VALIDATION_ENABLED", "match-validation-enabled"}
+	strictValidationSetting    = setting{"match_validation.strict", "MATCH_VALIDATION_STRICT", "match-validation-strict"}
+)
+
+// LoadConfig loads the server settings from the defaults, the config file, the environment variables and the given
+// command-line arguments, in increasing order of precedence, and validates them.
+// The config file is a YAML or JSON file named by the -config flag or the CONFIG_FILE environment variable.
+func LoadConfig(args []string) (Config, error) {
+	// the flags are parsed once up front only to find the config file, and once more after the config file and the
+	// environment are applied so that they take precedence over both
+	var configFile string
+	scratch := DefaultConfig()
+	if err := newConfigFlagSet(&scratch, &configFile, os.Stderr).Parse(args); err != nil {
+		return Config{}, err
+	}
+	if configFile == "" {
+		configFile = os.Getenv(configFileEnv)
+	}
+
+	cfg := DefaultConfig()
+	if configFile != "" {
+		if err := cfg.loadFile(configFile); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfigFlagSet(&cfg, &configFile, io.Discard).Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func newConfigFlagSet(cfg *Config, configFile *string, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	fs.StringVar(configFile, configFileFlag, *configFile, "path of the YAML or JSON config file (env "+configFileEnv+")")
+	fs.IntVar(&cfg.GRPCPort, grpcPortSetting.flag, cfg.GRPCPort, "gRPC server port")
+	fs.IntVar(&cfg.MetricsPort, metricsPortSetting.flag, cfg.MetricsPort, "prometheus metrics port")
+	fs.StringVar(&cfg.MetricsEndpoint, metricsEndpointSetting.flag, cfg.MetricsEndpoint, "prometheus metrics path")
+	fs.StringVar(&cfg.ServiceName, serviceNameSetting.flag, cfg.ServiceName, "service name reported to the tracer")
+	fs.StringVar(&cfg.Environment, environmentSetting.flag, cfg.Environment, "environment reported to the tracer")
+	fs.StringVar(&cfg.LogLevel, logLevelSetting.flag, cfg.LogLevel, "log level: debug, info, warn or error")
+	fs.StringVar(&cfg.ZipkinEndpoint, zipkinEndpointSetting.flag, cfg.ZipkinEndpoint, "zipkin spans endpoint")
+	fs.Var(&cfg.ShutdownGracePeriod, shutdownGracePeriodSetting.flag, "time given to in-flight streams on shutdown")
+	fs.BoolVar(&cfg.Auth.Enabled, authEnabledSetting.flag, cfg.Auth.Enabled, "validate the access token of the gRPC calls")
+	fs.StringVar(&cfg.Auth.Validator, authValidatorSetting.flag, cfg.Auth.Validator, "token validator: iam or offline")
+	fs.Var(&cfg.Auth.RefreshInterval, refreshIntervalSetting.flag, "refresh interval of the token validator")
+	fs.StringVar(&cfg.Auth.BaseURL, baseURLSetting.flag, cfg.Auth.BaseURL, "base URL of the AGS environment")
+	fs.StringVar(&cfg.Auth.ClientID, clientIDSetting.flag, cfg.Auth.ClientID, "AGS IAM client ID")
+	fs.StringVar(&cfg.Auth.ClientSecret, clientSecretSetting.flag, cfg.Auth.ClientSecret, "AGS IAM client secret")
+	fs.StringVar(&cfg.Auth.Namespace, namespaceSetting.flag, cfg.Auth.Namespace, "AGS namespace")
+	fs.StringVar(&cfg.Auth.KeysPath, keysPathSetting.flag, cfg.Auth.KeysPath, "JWKS file or directory of PEM public keys of the offline validator")
+	fs.StringVar(&cfg.Auth.RevocationListPath, revocationListPathSetting.flag, cfg.Auth.RevocationListPath, "revocation list file of the offline validator")
+	fs.StringVar(&cfg.TLS.CertFile, tlsCertFileSetting.flag, cfg.TLS.CertFile, "PEM certificate of the gRPC listener, enables TLS")
+	fs.StringVar(&cfg.TLS.KeyFile, tlsKeyFileSetting.flag, cfg.TLS.KeyFile, "PEM private key of the TLS certificate")
+	fs.StringVar(&cfg.TLS.ClientCAFile, tlsClientCAFileSetting.flag, cfg.TLS.ClientCAFile, "PEM CA bundle the client certificates are verified against, enables mutual TLS")
+	fs.BoolVar(&cfg.MatchValidation.Enabled, matchValidationSetting.flag, cfg.MatchValidation.Enabled, "check the matches before they are sent")
+	fs.BoolVar(&cfg.MatchValidation.Strict, strictValidationSetting.flag, cfg.MatchValidation.Strict, "drop the matches that fail the checks instead of only logging them")
+
+	return fs
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, c)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension %q, use .yaml, .yml or .json", path, filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	var errs []error
+	lookup := func(s setting, apply func(string) error) {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := apply(value); err != nil {
+				errs = append(errs, fmt.Errorf("env %s=%q: %w", s.env, value, err))
+			}
+		}
+	}
+	str := func(dst *string) func(string) error {
+		return func(value string) error {
+			*dst = value
+
+			return nil
+		}
+	}
+	integer := func(dst *int) func(string) error {
+		return func(value string) error {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.New("expected an integer")
+			}
+			*dst = parsed
+
+			return nil
+		}
+	}
+	boolean := func(dst *bool) func(string) error {
+		return func(value string) error {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.New("expected true or false")
+			}
+			*dst = parsed
+
+			return nil
+		}
+	}
+
+	lookup(grpcPortSetting, integer(&c.GRPCPort))
+	lookup(metricsPortSetting, integer(&c.MetricsPort))
+	lookup(metricsEndpointSetting, str(&c.MetricsEndpoint))
+	lookup(serviceNameSetting, func(value string) error {
+		if value != "" {
+			c.ServiceName = "extend-app-mm-" + strings.ToLower(value)
+		}
+
+		return nil
+	})
+	lookup(environmentSetting, str(&c.Environment))
+	lookup(logLevelSetting, str(&c.LogLevel))
+	lookup(zipkinEndpointSetting, str(&c.ZipkinEndpoint))
+	lookup(shutdownGracePeriodSetting, c.ShutdownGracePeriod.Set)
+	lookup(authEnabledSetting, boolean(&c.Auth.Enabled))
+	lookup(authValidatorSetting, str(&c.Auth.Validator))
+	lookup(refreshIntervalSetting, c.Auth.RefreshInterval.Set)
+	lookup(baseURLSetting, str(&c.Auth.BaseURL))
+	lookup(clientIDSetting, str(&c.Auth.ClientID))
+	lookup(clientSecretSetting, str(&c.Auth.ClientSecret))
+	lookup(namespaceSetting, str(&c.Auth.Namespace))
+	lookup(keysPathSetting, str(&c.Auth.KeysPath))
+	lookup(revocationListPathSetting, str(&c.Auth.RevocationListPath))
+	lookup(tlsCertFileSetting, str(&c.TLS.CertFile))
+	lookup(tlsKeyFileSetting, str(&c.TLS.KeyFile))
+	lookup(tlsClientCAFileSetting, str(&c.TLS.ClientCAFile))
+	lookup(matchValidationSetting, boolean(&c.MatchValidation.Enabled))
+	lookup(strictValidationSetting, boolean(&c.MatchValidation.Strict))
+
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid setting, naming where it can be set
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(s setting, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("invalid %s: %s", s, fmt.Sprintf(format, args...)))
+	}
+
+	if c.GRPCPort < 1 || c.GRPCPort > 65535 {
+		invalid(grpcPortSetting, "port must be between 1 and 65535, got %d", c.GRPCPort)
+	}
+	if c.MetricsPort < 1 || c.MetricsPort > 65535 {
+		invalid(metricsPortSetting, "port must be between 1 and 65535, got %d", c.MetricsPort)
+	}
+	if c.MetricsPort == c.GRPCPort {
+		invalid(metricsPortSetting, "port %d is already used by %s", c.MetricsPort, grpcPortSetting.key)
+	}
+	if !strings.HasPrefix(c.MetricsEndpoint, "/") {
+		invalid(metricsEndpointSetting, "path must start with /, got %q", c.MetricsEndpoint)
+	}
+	if c.ServiceName == "" {
+		invalid(serviceNameSetting, "must not be empty")
+	}
+	if _, ok := ParseLogLevel(c.LogLevel); !ok {
+		invalid(logLevelSetting, "must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	if c.ZipkinEndpoint == "" {
+		invalid(zipkinEndpointSetting, "must not be empty")
+	}
+	if c.ShutdownGracePeriod < 0 {
+		invalid(shutdownGracePeriodSetting, "must not be negative, got %s", c.ShutdownGracePeriod)
+	}
+
+	if c.Auth.Enabled {
+		if c.Auth.RefreshInterval <= 0 {
+			invalid(refreshIntervalSetting, "must be positive when auth is enabled, got %s", c.Auth.RefreshInterval)
+		}
+
+		switch c.Auth.Validator {
+		case AuthValidatorIAM:
+			// missing IAM settings are reported by Warnings only, so the server still starts without them
+		case AuthValidatorOffline:
+			for _, r := range c.requiredAuthSettings() {
+				if r.value == "" {
+					invalid(r.setting, "required when auth is enabled with the %s validator, or disable auth with %s", c.Auth.Validator, authEnabledSetting)
+				}
+			}
+			for _, path := range []requiredSetting{{keysPathSetting, c.Auth.KeysPath}, {revocationListPathSetting, c.Auth.RevocationListPath}} {
+				if path.value == "" {
+					continue
+				}
+				if _, err := os.Stat(path.value); err != nil {
+					invalid(path.setting, "%v", err)
+				}
+			}
+		default:
+			invalid(authValidatorSetting, "must be %s or %s, got %q", AuthValidatorIAM, AuthValidatorOffline, c.Auth.Validator)
+		}
+	}
+
+	if c.TLS.CertFile != "" && c.TLS.KeyFile == "" {
+		invalid(tlsKeyFileSetting, "required when %s is set", tlsCertFileSetting.key)
+	}
+	if c.TLS.KeyFile != "" && c.TLS.CertFile == "" {
+		invalid(tlsCertFileSetting, "required when %s is set", tlsKeyFileSetting.key)
+	}
+	if c.TLS.ClientCAFile != "" && c.TLS.CertFile == "" {
+		invalid(tlsCertFileSetting, "required when %s is set, mutual TLS needs a server certificate", tlsClientCAFileSetting.key)
+	}
+	for _, file := range []struct {
+		setting
+		value string
+	}{{tlsCertFileSetting, c.TLS.CertFile}, {tlsKeyFileSetting, c.TLS.KeyFile}, {tlsClientCAFileSetting, c.TLS.ClientCAFile}} {
+		if file.value == "" {
+			continue
+		}
+		if _, err := os.Stat(file.value); err != nil {
+			invalid(file.setting, "%v", err)
+		}
+	}
+
+	methods := matchFunctionMethods()
+	for _, method := range slices.Sorted(maps.Keys(c.Auth.Permissions)) {
+		permission := c.Auth.Permissions[method]
+		key := "auth.permissions." + method
+		if !methods[method] {
+			errs = append(errs, fmt.Errorf("invalid %s: unknown MatchFunction method, expected one of %s", key, strings.Join(slices.Sorted(maps.Keys(methods)), ", ")))
+		}
+		if permission.Resource == "" {
+			errs = append(errs, fmt.Errorf("invalid %s: resource must not be empty", key))
+		}
+		if permission.Action < permissionActionCreate || permission.Action > permissionActionAll {
+			errs = append(errs, fmt.Errorf("invalid %s: action must be a bitmask of CREATE (1), READ (2), UPDATE (4) and DELETE (8), got %d", key, permission.Action))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Warnings reports the settings that are missing but do not stop the server from starting. With auth enabled and the
+// IAM validator, the IAM settings are required for the validator to initialize, and every call is rejected without
+// them.
+func (c Config) Warnings() []error {
+	if !c.Auth.Enabled || c.Auth.Validator != AuthValidatorIAM {
+		return nil
+	}
+
+	var warnings []error
+	for _, r := range c.requiredAuthSettings() {
+		if r.value == "" {
+			warnings = append(warnings, fmt.Errorf("missing %s: required when auth is enabled with the %s validator, or disable auth with %s", r.setting, c.Auth.Validator, authEnabledSetting))
+		}
+	}
+
+	return warnings
+}
+
+type requiredSetting struct {
+	setting
+	value string
+}
+
+// requiredAuthSettings returns the settings the token validator needs
+func (c Config) requiredAuthSettings() []requiredSetting {
+	if c.Auth.Validator == AuthValidatorOffline {
+		return []requiredSetting{{namespaceSetting, c.Auth.Namespace}, {keysPathSetting, c.Auth.KeysPath}}
+	}
+
+	return []requiredSetting{
+		{baseURLSetting, c.Auth.BaseURL},
+		{clientIDSetting, c.Auth.ClientID},
+		{clientSecretSetting, c.Auth.ClientSecret},
+		{namespaceSetting, c.Auth.Namespace},
+	}
+}
+
+// matchFunctionMethods returns the names of the MatchFunction methods that can require a permission
+func matchFunctionMethods() map[string]bool {
+	methods := make(map[string]bool)
+	for _, method := range matchfunctiongrpc.MatchFunction_ServiceDesc.Methods {
+		methods[method.MethodName] = true
+	}
+	for _, stream := range matchfunctiongrpc.MatchFunction_ServiceDesc.Streams {
+		methods[stream.StreamName] = true
+	}
+
+	return methods
+}
+
+// Redacted returns a copy of the config with the secrets masked
+func (c Config) Redacted() Config {
+	if c.Auth.ClientSecret != "" {
+		c.Auth.ClientSecret = redactedValue
+	}
+
+	return c
+}
+
+// LogValue logs the config with the secrets masked
+func (c Config) LogValue() slog.Value {
+	// redactedConfig has no LogValue method, so logging it does not recurse
+	type redactedConfig Config
+
+	return slog.AnyValue(redactedConfig(c.Redacted()))
+}
+
+// ParseLogLevel maps a log level name to its slog level, reporting whether the name is known
+func ParseLogLevel(levelStr string) (slog.Level, bool) {
+	switch strings.ToLower(levelStr) {
+	case "debug":
+		return slog.LevelDebug, true
+	case "info":
+		return slog.LevelInfo, true
+	case "warn", "warning":
+		return slog.LevelWarn, true
+	case "error", "fatal", "panic":
+		return slog.LevelError, true
+	default:
+		return slog.LevelInfo, false
+	}
+}
+
+// Duration is a time.Duration that can be set from a Go duration string such as "30s", or from a number of seconds
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Set parses a Go duration string or a number of seconds
+func (d *Duration) Set(value string) error {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		*d = Duration(time.Duration(seconds) * time.Second)
+
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("expected a duration such as \"30s\" or a number of seconds, got %q", value)
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		// not a string, so expect a number of seconds
+		return d.Set(string(data))
+	}
+
+	return d.Set(value)
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+
+	return d.Set(value)
+}
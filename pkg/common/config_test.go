@@ -0,0 +1,52 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"testing"
+)
+
+func TestConfigAuthSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     AuthConfig
+		invalid  bool
+		warnings int
+	}{
+		{
+			name: "iam without settings starts with warnings",
+			auth: AuthConfig{Enabled: true, Validator: AuthValidatorIAM, RefreshInterval: 1},
+			// base_url, client_id, client_secret and namespace
+			warnings: 4,
+		},
+		{
+			name: "iam with settings",
+			auth: AuthConfig{Enabled: true, Validator: AuthValidatorIAM, RefreshInterval: 1, BaseURL: "https://test.accelbyte.io", ClientID: "id", ClientSecret: "secret", Namespace: "namespace"},
+		},
+		{
+			name:    "offline without keys",
+			auth:    AuthConfig{Enabled: true, Validator: AuthValidatorOffline, RefreshInterval: 1, Namespace: "namespace"},
+			invalid: true,
+		},
+		{
+			name: "auth disabled",
+			auth: AuthConfig{Validator: AuthValidatorIAM},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Auth = tt.auth
+
+			if err := config.Validate(); (err != nil) != tt.invalid {
+				t.Errorf("expected invalid %v, got %v", tt.invalid, err)
+			}
+			if warnings := config.Warnings(); len(warnings) != tt.warnings {
+				t.Errorf("expected %d warnings, got %v", tt.warnings, warnings)
+			}
+		})
+	}
+}
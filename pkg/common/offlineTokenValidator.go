@@ -0,0 +1,399 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/utils/auth/validator"
+	jose "github.com/AccelByte/go-jose"
+	"github.com/AccelByte/go-jose/jwt"
+)
+
+const (
+	offlineTokenAlgorithm = "RS256"
+	// offlineTokenClockSkew is how far the clock of the token issuer may be off when checking the token times
+	offlineTokenClockSkew = 30 * time.Second
+)
+
+// OfflineTokenValidator validates RS256 access tokens against local public keys instead of the AGS IAM service,
+// for local development, CI and air-gapped test environments.
+// The keys are read from a JWKS file, or from a directory of PEM files where each file name, without its
+// extension, is the key ID. Permissions are only checked against the permissions carried in the token, since
+// the role permissions can not be fetched offline.
+type OfflineTokenValidator struct {
+	KeysPath           string
+	RevocationListPath string
+	RefreshInterval    time.Duration
+
+	lock          sync.RWMutex
+	publicKeys    map[string]*rsa.PublicKey
+	revokedTokens map[string]bool
+	revokedUsers  map[string]time.Time
+}
+
+// RevocationList is the format of the revocation list file of the OfflineTokenValidator.
+// A revoked token is listed either as the whole token or as its JWT ID. A revoked user invalidates the tokens
+// issued to the user up to the revocation time.
+type RevocationList struct {
+	RevokedTokens []string            `json:"revoked_tokens"`
+	RevokedUsers  []RevokedUserRecord `json:"revoked_users"`
+}
+
+// RevokedUserRecord revokes the tokens of a user issued up to RevokedAt
+type RevokedUserRecord struct {
+	ID        string    `json:"id"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+// NewOfflineTokenValidator creates an OfflineTokenValidator reading its keys from keysPath and, when set, its
+// revocation list from revocationListPath. Both are reloaded every refreshInterval once initialized.
+func NewOfflineTokenValidator(keysPath string, revocationListPath string, refreshInterval time.Duration) validator.AuthTokenValidator {
+	return &OfflineTokenValidator{
+		KeysPath:           keysPath,
+		RevocationListPath: revocationListPath,
+		RefreshInterval:    refreshInterval,
+	}
+}
+
+// Initialize loads the keys and the revocation list, and reloads them every RefreshInterval until the context
+// is done
+func (v *OfflineTokenValidator) Initialize(ctx ...context.Context) error {
+	if err := v.load(); err != nil {
+		return err
+	}
+
+	if v.RefreshInterval > 0 && len(ctx) > 0 && ctx[0] != nil {
+		go v.refresh(ctx[0])
+	}
+
+	return nil
+}
+
+func (v *OfflineTokenValidator) refresh(ctx context.Context) {
+	ticker := time.NewTicker(v.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := v.load(); err != nil {
+				slog.Default().Error("could not reload offline token validator, keeping the previous keys", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (v *OfflineTokenValidator) load() error {
+	publicKeys, err := loadPublicKeys(v.KeysPath)
+	if err != nil {
+		return err
+	}
+
+	revokedTokens := make(map[string]bool)
+	revokedUsers := make(map[string]time.Time)
+	if v.RevocationListPath != "" {
+		revocationList, err := loadRevocationList(v.RevocationListPath)
+		if err != nil {
+			return err
+		}
+		for _, token := range revocationList.RevokedTokens {
+			revokedTokens[token] = true
+		}
+		for _, user := range revocationList.RevokedUsers {
+			revokedUsers[user.ID] = user.RevokedAt
+		}
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.publicKeys = publicKeys
+	v.revokedTokens = revokedTokens
+	v.revokedUsers = revokedUsers
+
+	return nil
+}
+
+func (v *OfflineTokenValidator) Validate(token string, permission *iam.Permission, namespace *string, userId *string) error {
+	jsonWebToken, err := jwt.ParseSigned(token)
+	if err != nil {
+		return err
+	}
+
+	if len(jsonWebToken.Headers) == 0 {
+		return errors.New("no headers found")
+	}
+
+	header := jsonWebToken.Headers[0]
+	if header.Algorithm != offlineTokenAlgorithm {
+		return fmt.Errorf("unsupported signing algorithm %q, expected %s", header.Algorithm, offlineTokenAlgorithm)
+	}
+	if header.KeyID == "" {
+		return errors.New("'kid' header not found")
+	}
+
+	v.lock.RLock()
+	publicKey := v.publicKeys[header.KeyID]
+	v.lock.RUnlock()
+	if publicKey == nil {
+		return fmt.Errorf("public key %q not found", header.KeyID)
+	}
+
+	var claims JWTClaims
+	if err := jsonWebToken.Claims(publicKey, &claims); err != nil {
+		return err
+	}
+
+	if err := validateTokenTimes(claims.Claims, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if v.isRevoked(token, claims) {
+		return errors.New("token was revoked")
+	}
+
+	if err := hasValidNamespace(claims, namespace); err != nil {
+		return err
+	}
+
+	if permission != nil && permission.Resource != "" {
+		resource := permission.Resource
+		if namespace != nil {
+			resource = strings.ReplaceAll(resource, "{namespace}", *namespace)
+		}
+		if userId != nil {
+			resource = strings.ReplaceAll(resource, "{userId}", *userId)
+		}
+
+		if !hasPermission(claims.Permissions, resource, permission.Action) {
+			return fmt.Errorf("insufficient permissions in offline validation, required [%s][%d]", resource, permission.Action)
+		}
+	}
+
+	return nil
+}
+
+func (v *OfflineTokenValidator) isRevoked(token string, claims JWTClaims) bool {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if v.revokedTokens[token] || (claims.ID != "" && v.revokedTokens[claims.ID]) {
+		return true
+	}
+
+	if revokedAt, found := v.revokedUsers[claims.Subject]; found {
+		return revokedAt.Unix() >= int64(claims.IssuedAt)
+	}
+
+	return false
+}
+
+// validateTokenTimes checks the token has an expiry, was not issued in the future and is valid at now, allowing
+// offlineTokenClockSkew
+func validateTokenTimes(claims jwt.Claims, now time.Time) error {
+	if claims.Expiry == 0 {
+		return errors.New("token has no expiry (exp)")
+	}
+	if claims.IssuedAt != 0 && now.Add(offlineTokenClockSkew).Before(claims.IssuedAt.Time()) {
+		return errors.New("token is issued in the future (iat)")
+	}
+
+	return claims.ValidateWithLeeway(jwt.Expected{Time: now}, offlineTokenClockSkew)
+}
+
+// hasValidNamespace checks the token was issued for the namespace, using its Extend namespace when it has one
+func hasValidNamespace(claims JWTClaims, namespace *string) error {
+	if namespace == nil || *namespace == "" {
+		return errors.New("trying to validate access token against a namespace, but have an empty namespace")
+	}
+
+	tokenNamespace := claims.Namespace
+	if claims.ExtendNamespace != "" {
+		tokenNamespace = claims.ExtendNamespace
+	}
+	if tokenNamespace != *namespace {
+		return fmt.Errorf("token namespace %q does not match namespace %q", tokenNamespace, *namespace)
+	}
+
+	return nil
+}
+
+// hasPermission checks one of the permissions grants the action on the resource. A "*" resource segment matches any
+// segment, and a trailing "*" segment matches all the remaining segments.
+func hasPermission(permissions []Permission, resource string, action int) bool {
+	required := strings.Split(resource, ":")
+	for _, permission := range permissions {
+		if permission.Action&action != action {
+			continue
+		}
+
+		granted := strings.Split(permission.Resource, ":")
+		if resourceMatches(granted, required) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resourceMatches(granted []string, required []string) bool {
+	for i, segment := range granted {
+		if i >= len(required) {
+			// a longer granted resource only matches with wildcards for the extra segments
+			for _, extra := range granted[i:] {
+				if extra != "*" {
+					return false
+				}
+			}
+
+			return true
+		}
+		if segment == "*" && i == len(granted)-1 {
+			return true
+		}
+		if segment != "*" && segment != required[i] {
+			return false
+		}
+	}
+
+	return len(granted) == len(required)
+}
+
+func loadPublicKeys(path string) (map[string]*rsa.PublicKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read public keys: %w", err)
+	}
+
+	if info.IsDir() {
+		return loadPEMKeys(path)
+	}
+
+	return loadJWKS(path)
+}
+
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read JWKS file: %w", err)
+	}
+
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keySet); err != nil {
+		return nil, fmt.Errorf("could not parse JWKS file %s: %w", path, err)
+	}
+
+	publicKeys := make(map[string]*rsa.PublicKey)
+	for _, key := range keySet.Keys {
+		publicKey, ok := key.Key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("JWKS file %s: key %q is not an RSA public key", path, key.KeyID)
+		}
+		if key.KeyID == "" {
+			return nil, fmt.Errorf("JWKS file %s: key without a kid", path)
+		}
+		publicKeys[key.KeyID] = publicKey
+	}
+
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s has no keys", path)
+	}
+
+	return publicKeys, nil
+}
+
+func loadPEMKeys(dir string) (map[string]*rsa.PublicKey, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeys := make(map[string]*rsa.PublicKey)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read PEM file: %w", err)
+		}
+
+		publicKey, err := parsePEMPublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("PEM file %s: %w", file, err)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		publicKeys[kid] = publicKey
+	}
+
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("no .pem files found in %s", dir)
+	}
+
+	return publicKeys, nil
+}
+
+func parsePEMPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("not an RSA public key")
+		}
+
+		return publicKey, nil
+	case "CERTIFICATE":
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("not an RSA certificate")
+		}
+
+		return publicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func loadRevocationList(path string) (RevocationList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RevocationList{}, fmt.Errorf("could not read revocation list: %w", err)
+	}
+
+	var revocationList RevocationList
+	if err := json.Unmarshal(data, &revocationList); err != nil {
+		return RevocationList{}, fmt.Errorf("could not parse revocation list %s: %w", path, err)
+	}
+
+	return revocationList, nil
+}
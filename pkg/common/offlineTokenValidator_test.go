@@ -0,0 +1,361 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/accelbyte-go-sdk/services-api/pkg/service/iam"
+	jose "github.com/AccelByte/go-jose"
+	"github.com/AccelByte/go-jose/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testSigningKey returns a new RSA key and a signer using it with the key ID "test"
+func testSigningKey(t *testing.T) (*rsa.PrivateKey, jose.Signer) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, signer
+}
+
+// signToken returns the claims signed by the signer, issued now and valid for an hour unless the claims set an issue
+// or expiry time
+func signToken(t *testing.T, signer jose.Signer, claims JWTClaims) string {
+	if claims.IssuedAt == 0 && claims.Expiry == 0 {
+		claims.IssuedAt = jwt.NewNumericDate(time.Now())
+		claims.Expiry = jwt.NewNumericDate(time.Now().Add(time.Hour))
+	}
+
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+// writeFile writes the data to the named file of the directory and returns the path of the file
+func writeFile(t *testing.T, dir string, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestOfflineTokenValidatorTimes(t *testing.T) {
+	key, signer := testSigningKey(t)
+	validator := &OfflineTokenValidator{publicKeys: map[string]*rsa.PublicKey{"test": &key.PublicKey}}
+
+	now := time.Now()
+	at := func(offset time.Duration) jwt.NumericDate {
+		return jwt.NewNumericDate(now.Add(offset))
+	}
+
+	tests := []struct {
+		name    string
+		claims  jwt.Claims
+		wantErr bool
+	}{
+		{name: "valid", claims: jwt.Claims{IssuedAt: at(-time.Minute), Expiry: at(time.Hour)}},
+		{name: "no expiry", claims: jwt.Claims{IssuedAt: at(-time.Minute)}, wantErr: true},
+		{name: "expired", claims: jwt.Claims{IssuedAt: at(-2 * time.Hour), Expiry: at(-time.Hour)}, wantErr: true},
+		{name: "expired within the clock skew", claims: jwt.Claims{Expiry: at(-10 * time.Second)}},
+		{name: "expired past the clock skew", claims: jwt.Claims{Expiry: at(-offlineTokenClockSkew - 10*time.Second)}, wantErr: true},
+		{name: "issued in the future", claims: jwt.Claims{IssuedAt: at(time.Hour), Expiry: at(2 * time.Hour)}, wantErr: true},
+		{name: "issued in the future within the clock skew", claims: jwt.Claims{IssuedAt: at(10 * time.Second), Expiry: at(time.Hour)}},
+		{name: "issued in the future past the clock skew", claims: jwt.Claims{IssuedAt: at(offlineTokenClockSkew + 10*time.Second), Expiry: at(time.Hour)}, wantErr: true},
+		{name: "not valid yet", claims: jwt.Claims{NotBefore: at(time.Hour), Expiry: at(2 * time.Hour)}, wantErr: true},
+		{name: "not valid yet within the clock skew", claims: jwt.Claims{NotBefore: at(10 * time.Second), Expiry: at(time.Hour)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signToken(t, signer, JWTClaims{Namespace: "namespace", Claims: tt.claims})
+
+			namespace := "namespace"
+			err := validator.Validate(token, nil, &namespace, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestOfflineTokenValidatorKeys(t *testing.T) {
+	key, signer := testSigningKey(t)
+	token := signToken(t, signer, JWTClaims{Namespace: "namespace"})
+
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		keysPath   func(dir string) string
+		initErr    bool
+		validateOK bool
+	}{
+		{
+			name:       "jwks file",
+			keysPath:   func(dir string) string { return writeFile(t, dir, "jwks.json", jwks) },
+			validateOK: true,
+		},
+		{
+			name: "directory of pem keys",
+			keysPath: func(dir string) string {
+				writeFile(t, dir, "test.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))
+
+				return dir
+			},
+			validateOK: true,
+		},
+		{
+			name: "directory of pkcs1 pem keys",
+			keysPath: func(dir string) string {
+				writeFile(t, dir, "test.pem", pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)}))
+
+				return dir
+			},
+			validateOK: true,
+		},
+		{
+			name: "pem key named after another key ID",
+			keysPath: func(dir string) string {
+				writeFile(t, dir, "other.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))
+
+				return dir
+			},
+		},
+		{
+			name:     "directory without pem keys",
+			keysPath: func(dir string) string { return dir },
+			initErr:  true,
+		},
+		{
+			name:     "jwks file that is not json",
+			keysPath: func(dir string) string { return writeFile(t, dir, "jwks.json", []byte("keys")) },
+			initErr:  true,
+		},
+		{
+			name:     "missing path",
+			keysPath: func(dir string) string { return filepath.Join(dir, "missing") },
+			initErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewOfflineTokenValidator(tt.keysPath(t.TempDir()), "", 0)
+
+			if err := validator.Initialize(); (err != nil) != tt.initErr {
+				t.Fatalf("expected an initialize error %v, got %v", tt.initErr, err)
+			}
+			if tt.initErr {
+				return
+			}
+
+			namespace := "namespace"
+			if err := validator.Validate(token, nil, &namespace, nil); (err == nil) != tt.validateOK {
+				t.Errorf("expected the token valid %v, got %v", tt.validateOK, err)
+			}
+		})
+	}
+}
+
+func TestOfflineTokenValidatorRevocation(t *testing.T) {
+	key, signer := testSigningKey(t)
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	token := func(id string, subject string, issuedAt time.Time) string {
+		return signToken(t, signer, JWTClaims{Namespace: "namespace", Claims: jwt.Claims{
+			ID:       id,
+			Subject:  subject,
+			IssuedAt: jwt.NewNumericDate(issuedAt),
+			Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+		}})
+	}
+	revokedToken := token("", "user", now.Add(-time.Minute))
+
+	tests := []struct {
+		name    string
+		token   string
+		revoked bool
+	}{
+		{name: "not revoked", token: token("id", "user", now.Add(-time.Minute))},
+		{name: "revoked token", token: revokedToken, revoked: true},
+		{name: "revoked token ID", token: token("revoked-id", "user", now.Add(-time.Minute)), revoked: true},
+		{name: "issued before the user was revoked", token: token("id", "revoked-user", now.Add(-2*time.Hour)), revoked: true},
+		{name: "issued after the user was revoked", token: token("id", "revoked-user", now.Add(-time.Minute))},
+	}
+
+	dir := t.TempDir()
+	revocationList, err := json.Marshal(RevocationList{
+		RevokedTokens: []string{revokedToken, "revoked-id"},
+		RevokedUsers:  []RevokedUserRecord{{ID: "revoked-user", RevokedAt: now.Add(-time.Hour)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator := NewOfflineTokenValidator(writeFile(t, dir, "jwks.json", jwks), writeFile(t, dir, "revoked.json", revocationList), 0)
+	if err := validator.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := "namespace"
+			if err := validator.Validate(tt.token, nil, &namespace, nil); (err != nil) != tt.revoked {
+				t.Errorf("expected the token revoked %v, got %v", tt.revoked, err)
+			}
+		})
+	}
+}
+
+func TestOfflineTokenValidatorNamespaceAndPermissions(t *testing.T) {
+	key, signer := testSigningKey(t)
+	validator := &OfflineTokenValidator{publicKeys: map[string]*rsa.PublicKey{"test": &key.PublicKey}}
+
+	const read, update = 2, 4
+	tests := []struct {
+		name       string
+		claims     JWTClaims
+		namespace  string
+		permission *iam.Permission
+		userID     string
+		wantErr    bool
+	}{
+		{name: "namespace", claims: JWTClaims{Namespace: "namespace"}, namespace: "namespace"},
+		{name: "other namespace", claims: JWTClaims{Namespace: "other"}, namespace: "namespace", wantErr: true},
+		{name: "extend namespace", claims: JWTClaims{Namespace: "studio", ExtendNamespace: "namespace"}, namespace: "namespace"},
+		{name: "no namespace", claims: JWTClaims{Namespace: "namespace"}, wantErr: true},
+		{
+			name:       "permission granted",
+			claims:     JWTClaims{Namespace: "namespace", Permissions: []Permission{{Resource: "NAMESPACE:namespace:MATCHMAKING", Action: read | update}}},
+			namespace:  "namespace",
+			permission: &iam.Permission{Resource: "NAMESPACE:{namespace}:MATCHMAKING", Action: read},
+		},
+		{
+			name:       "permission without the action",
+			claims:     JWTClaims{Namespace: "namespace", Permissions: []Permission{{Resource: "NAMESPACE:namespace:MATCHMAKING", Action: read}}},
+			namespace:  "namespace",
+			permission: &iam.Permission{Resource: "NAMESPACE:{namespace}:MATCHMAKING", Action: update},
+			wantErr:    true,
+		},
+		{
+			name:       "permission on another namespace",
+			claims:     JWTClaims{Namespace: "namespace", Permissions: []Permission{{Resource: "NAMESPACE:other:MATCHMAKING", Action: read}}},
+			namespace:  "namespace",
+			permission: &iam.Permission{Resource: "NAMESPACE:{namespace}:MATCHMAKING", Action: read},
+			wantErr:    true,
+		},
+		{
+			name:       "wildcard segment",
+			claims:     JWTClaims{Namespace: "namespace", Permissions: []Permission{{Resource: "NAMESPACE:*:MATCHMAKING", Action: read}}},
+			namespace:  "namespace",
+			permission: &iam.Permission{Resource: "NAMESPACE:{namespace}:MATCHMAKING", Action: read},
+		},
+		{
+			name:       "trailing wildcard",
+			claims:     JWTClaims{Namespace: "namespace", Permissions: []Permission{{Resource: "NAMESPACE:namespace:*", Action: read}}},
+			namespace:  "namespace",
+			permission: &iam.Permission{Resource: "NAMESPACE:{namespace}:USER:{userId}", Action: read},
+			userID:     "user",
+		},
+		{
+			name:       "user resource of another user",
+			claims:     JWTClaims{Namespace: "namespace", Permissions: []Permission{{Resource: "NAMESPACE:namespace:USER:other", Action: read}}},
+			namespace:  "namespace",
+			permission: &iam.Permission{Resource: "NAMESPACE:{namespace}:USER:{userId}", Action: read},
+			userID:     "user",
+			wantErr:    true,
+		},
+		{name: "no permission required", claims: JWTClaims{Namespace: "namespace"}, namespace: "namespace", permission: &iam.Permission{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID *string
+			if tt.userID != "" {
+				userID = &tt.userID
+			}
+
+			err := validator.Validate(signToken(t, signer, tt.claims), tt.permission, &tt.namespace, userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestOfflineTokenValidatorRequiredPermissions(t *testing.T) {
+	key, signer := testSigningKey(t)
+	token := signToken(t, signer, JWTClaims{
+		Namespace:   "namespace",
+		Permissions: []Permission{{Resource: "NAMESPACE:namespace:MATCHMAKING", Action: 2}},
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		code   codes.Code
+	}{
+		{name: "permission granted", method: "/service.MatchFunction/MakeMatches"},
+		{name: "permission missing", method: "/service.MatchFunction/BackfillMatches", code: codes.PermissionDenied},
+		{name: "no permission required", method: "/service.MatchFunction/GetStatCodes"},
+	}
+
+	savedValidator, savedNamespace, savedPermissions := Validator, Namespace, RequiredPermissions
+	defer func() {
+		Validator, Namespace, RequiredPermissions = savedValidator, savedNamespace, savedPermissions
+	}()
+	Validator = &OfflineTokenValidator{publicKeys: map[string]*rsa.PublicKey{"test": &key.PublicKey}}
+	Namespace = "namespace"
+	RequiredPermissions = map[string]Permission{
+		"MakeMatches":     {Resource: "NAMESPACE:{namespace}:MATCHMAKING", Action: 2},
+		"BackfillMatches": {Resource: "NAMESPACE:{namespace}:MATCHMAKING", Action: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+			handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+
+			_, err := UnaryAuthServerIntercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.code {
+				t.Errorf("expected the code %v, got %v", tt.code, err)
+			}
+		})
+	}
+}
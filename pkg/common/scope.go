@@ -59,6 +59,11 @@ type Scope struct {
 	Log     *slog.Logger
 }
 
+// Claims returns the claims of the access token the call was authorized with. There are none when auth is disabled.
+func (s *Scope) Claims() (*JWTClaims, bool) {
+	return ClaimsFromContext(s.Ctx)
+}
+
 // Finish finishes current scope
 func (s *Scope) Finish() {
 	s.span.End()
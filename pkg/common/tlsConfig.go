@@ -0,0 +1,157 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsReloadCheckInterval is how often, at most, the certificate files are checked for changes
+const tlsReloadCheckInterval = 5 * time.Second
+
+// NewServerTLSConfig returns the TLS config of the gRPC server. The certificate, and the client CA bundle when
+// client certificates are verified, are reloaded on the next handshake after their files change, so a renewed
+// certificate is served without restarting the server.
+func NewServerTLSConfig(certFile string, keyFile string, clientCAFile string) (*tls.Config, error) {
+	reloader := &tlsReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+	}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: reloader.getConfigForClient,
+	}, nil
+}
+
+type tlsReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	lock        sync.Mutex
+	config      *tls.Config
+	modTimes    []time.Time
+	lastChecked time.Time
+}
+
+func (r *tlsReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+
+	return files
+}
+
+func (r *tlsReloader) load() error {
+	modTimes, err := fileModTimes(r.files())
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("could not load TLS certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+		// the config replaces the one gRPC set up, so it has to advertise HTTP/2 itself
+		NextProtos: []string{"h2"},
+	}
+
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("could not read TLS client CA bundle: %w", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in TLS client CA bundle %s", r.clientCAFile)
+		}
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.config = config
+	r.modTimes = modTimes
+	r.lastChecked = time.Now()
+
+	return nil
+}
+
+func (r *tlsReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	if r.changed() {
+		if err := r.load(); err != nil {
+			slog.Default().Error("could not reload TLS certificate, keeping the previous one", "error", err)
+		} else {
+			slog.Default().Info("reloaded TLS certificate", "certFile", r.certFile)
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.config, nil
+}
+
+// changed reports whether the files were modified since they were loaded, checking at most every
+// tlsReloadCheckInterval
+func (r *tlsReloader) changed() bool {
+	r.lock.Lock()
+	if time.Since(r.lastChecked) < tlsReloadCheckInterval {
+		r.lock.Unlock()
+
+		return false
+	}
+	r.lastChecked = time.Now()
+	loaded := r.modTimes
+	r.lock.Unlock()
+
+	modTimes, err := fileModTimes(r.files())
+	if err != nil {
+		// a file being replaced may be missing for a moment, it is checked again on a later handshake
+		return false
+	}
+
+	for i := range modTimes {
+		if !modTimes[i].Equal(loaded[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fileModTimes(files []string) ([]time.Time, error) {
+	modTimes := make([]time.Time, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return nil, errors.New(file + " is a directory")
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+
+	return modTimes, nil
+}
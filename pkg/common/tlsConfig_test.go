@@ -0,0 +1,203 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate for the common name, and its key, to cert.pem and key.pem
+// in the directory, with the files modified at modTime
+func writeTestCertificate(t *testing.T, dir string, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, dir, "cert.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, dir, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	for _, name := range []string{"cert.pem", "key.pem"} {
+		if err := os.Chtimes(filepath.Join(dir, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// servedCommonName returns the common name of the certificate the config serves
+func servedCommonName(t *testing.T, config *tls.Config) string {
+	certificate, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return certificate.Subject.CommonName
+}
+
+func TestNewServerTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCertificate(t, dir, "first", time.Now())
+
+	if _, err := NewServerTLSConfig(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "missing.pem"), ""); err == nil {
+		t.Error("expected an error for a missing key file")
+	}
+
+	config, err := NewServerTLSConfig(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := servedCommonName(t, served); name != "first" {
+		t.Errorf("expected the certificate first to be served, got %s", name)
+	}
+	if served.ClientAuth != tls.NoClientCert {
+		t.Errorf("expected no client certificate to be required, got %v", served.ClientAuth)
+	}
+}
+
+func TestTLSReloaderRotation(t *testing.T) {
+	loadedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		rotate       func(t *testing.T, dir string)
+		checkedSince time.Duration // how long ago the files were last checked
+		served       string
+	}{
+		{
+			name:         "rotated certificate served",
+			rotate:       func(t *testing.T, dir string) { writeTestCertificate(t, dir, "second", time.Now()) },
+			checkedSince: tlsReloadCheckInterval,
+			served:       "second",
+		},
+		{
+			name:         "unchanged files",
+			rotate:       func(t *testing.T, dir string) {},
+			checkedSince: tlsReloadCheckInterval,
+			served:       "first",
+		},
+		{
+			name:         "rotation noticed at the next check",
+			rotate:       func(t *testing.T, dir string) { writeTestCertificate(t, dir, "second", time.Now()) },
+			checkedSince: 0,
+			served:       "first",
+		},
+		{
+			name: "invalid certificate keeps the previous one",
+			rotate: func(t *testing.T, dir string) {
+				writeFile(t, dir, "cert.pem", []byte("certificate"))
+			},
+			checkedSince: tlsReloadCheckInterval,
+			served:       "first",
+		},
+		{
+			name: "missing certificate keeps the previous one",
+			rotate: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "cert.pem")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			checkedSince: tlsReloadCheckInterval,
+			served:       "first",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestCertificate(t, dir, "first", loadedAt)
+			reloader := &tlsReloader{certFile: filepath.Join(dir, "cert.pem"), keyFile: filepath.Join(dir, "key.pem")}
+			if err := reloader.load(); err != nil {
+				t.Fatal(err)
+			}
+			config := &tls.Config{GetConfigForClient: reloader.getConfigForClient}
+
+			tt.rotate(t, dir)
+			reloader.lastChecked = time.Now().Add(-tt.checkedSince)
+
+			served, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name := servedCommonName(t, served); name != tt.served {
+				t.Errorf("expected the certificate %s to be served, got %s", tt.served, name)
+			}
+		})
+	}
+}
+
+func TestTLSReloaderClientCA(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCertificate(t, dir, "first", time.Now())
+
+	tests := []struct {
+		name       string
+		clientCA   []byte
+		wantErr    bool
+		clientAuth tls.ClientAuthType
+	}{
+		{name: "client certificates verified", clientCA: readFile(t, filepath.Join(dir, "cert.pem")), clientAuth: tls.RequireAndVerifyClientCert},
+		{name: "bundle without certificates", clientCA: []byte("bundle"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCAFile := writeFile(t, t.TempDir(), "ca.pem", tt.clientCA)
+
+			config, err := NewServerTLSConfig(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), clientCAFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected an error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			served, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if served.ClientAuth != tt.clientAuth || served.ClientCAs == nil {
+				t.Errorf("expected client auth %v with the CA bundle, got %v", tt.clientAuth, served.ClientAuth)
+			}
+		})
+	}
+}
+
+// readFile returns the content of the file
+func readFile(t *testing.T, path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
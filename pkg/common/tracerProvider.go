@@ -15,8 +15,7 @@ import (
 	semanticConventions "go.opentelemetry.io/otel/semconv/v1.12.0"
 )
 
-func NewTracerProvider(serviceName string, environment string, id int64) (*sdkTrace.TracerProvider, error) {
-	zipkinEndpoint := GetEnv("OTEL_EXPORTER_ZIPKIN_ENDPOINT", "http://localhost:9411/api/v2/spans")
+func NewTracerProvider(serviceName string, environment string, id int64, zipkinEndpoint string) (*sdkTrace.TracerProvider, error) {
 	exporter, err := zipkin.New(zipkinEndpoint)
 	if err != nil {
 		return nil, err
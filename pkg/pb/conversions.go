@@ -78,6 +78,8 @@ func MatchfunctionTicketsToProtoTickets(tickets []matchmaker.Ticket) []*Ticket {
 	return result
 }
 
+// ProtoTicketToMatchfunctionTicket converts a proto ticket to a matchmaker ticket.
+// The players of the ticket are members of the party of the ticket's party session.
 func ProtoTicketToMatchfunctionTicket(ticket *Ticket) matchmaker.Ticket {
 	return matchmaker.Ticket{
 		TicketID:  ticket.TicketId,
@@ -86,6 +88,7 @@ func ProtoTicketToMatchfunctionTicket(ticket *Ticket) matchmaker.Ticket {
 		Players: pie.Map(ticket.Players, func(p *Ticket_PlayerData) playerdata.PlayerData {
 			return playerdata.PlayerData{
 				PlayerID:   playerdata.IDFromString(p.PlayerId),
+				PartyID:    ticket.PartySessionId,
 				Attributes: p.Attributes.AsMap(),
 			}
 		}),
@@ -130,21 +133,7 @@ func protoBackfillTicketTeamToMatch(protoTeams []*BackfillTicket_Team) []matchma
 
 func ProtoPartialMatchToMatchfunctionMatch(match *BackfillTicket_PartialMatch) matchmaker.Match {
 	return matchmaker.Match{
-		Tickets: pie.Map(match.Tickets, func(m *Ticket) matchmaker.Ticket {
-			return matchmaker.Ticket{
-				TicketID:  m.TicketId,
-				MatchPool: m.MatchPool,
-				CreatedAt: m.CreatedAt.AsTime(),
-				Players: pie.Map(m.Players, func(p *Ticket_PlayerData) playerdata.PlayerData {
-					return playerdata.PlayerData{PlayerID: playerdata.IDFromString(p.PlayerId), Attributes: p.Attributes.AsMap()}
-				}),
-				TicketAttributes: m.TicketAttributes.AsMap(),
-				Latencies:        m.Latencies,
-				PartySessionID:   m.PartySessionId,
-				Namespace:        m.Namespace,
-				ExcludedSessions: m.ExcludedSessions,
-			}
-		}),
+		Tickets:          pie.Map(match.Tickets, ProtoTicketToMatchfunctionTicket),
 		Teams:            protoBackfillTicketTeamToMatch(match.Teams),
 		RegionPreference: match.RegionPreferences,
 		MatchAttributes:  match.MatchAttributes.AsMap(),
@@ -165,21 +154,7 @@ func ProtoMatchToMatchfunctionMatch(match *Match) matchmaker.Match {
 	}
 
 	return matchmaker.Match{
-		Tickets: pie.Map(match.Tickets, func(m *Ticket) matchmaker.Ticket {
-			return matchmaker.Ticket{
-				TicketID:  m.TicketId,
-				MatchPool: m.MatchPool,
-				CreatedAt: m.CreatedAt.AsTime(),
-				Players: pie.Map(m.Players, func(p *Ticket_PlayerData) playerdata.PlayerData {
-					return playerdata.PlayerData{PlayerID: playerdata.IDFromString(p.PlayerId), Attributes: p.Attributes.AsMap()}
-				}),
-				TicketAttributes: m.TicketAttributes.AsMap(),
-				Latencies:        m.Latencies,
-				PartySessionID:   m.PartySessionId,
-				Namespace:        m.Namespace,
-				ExcludedSessions: m.ExcludedSessions,
-			}
-		}),
+		Tickets:                      pie.Map(match.Tickets, ProtoTicketToMatchfunctionTicket),
 		Teams:                        protoMatchTeamToMatch(match.Teams),
 		RegionPreference:             match.RegionPreferences,
 		MatchAttributes:              match.MatchAttributes.AsMap(),
@@ -258,26 +233,11 @@ func MatchfunctionMatchToProtoMatch(match matchmaker.Match) *Match {
 	}
 }
 
-// TODO: update to consider userID and partyID relationship.
 func ProtoBackfillProposalToMatchfunctionBackfillProposal(match *BackfillProposal) matchmaker.BackfillProposal {
 	return matchmaker.BackfillProposal{
 		BackfillTicketID: match.BackfillTicketId,
 		CreatedAt:        match.CreatedAt.AsTime(),
-		AddedTickets: pie.Map(match.AddedTickets, func(m *Ticket) matchmaker.Ticket {
-			return matchmaker.Ticket{
-				TicketID:  m.TicketId,
-				MatchPool: m.MatchPool,
-				CreatedAt: m.CreatedAt.AsTime(),
-				Players: pie.Map(m.Players, func(p *Ticket_PlayerData) playerdata.PlayerData {
-					return playerdata.PlayerData{PlayerID: playerdata.IDFromString(p.PlayerId), Attributes: p.Attributes.AsMap()}
-				}),
-				TicketAttributes: m.TicketAttributes.AsMap(),
-				Latencies:        m.Latencies,
-				PartySessionID:   m.PartySessionId,
-				Namespace:        m.Namespace,
-				ExcludedSessions: m.ExcludedSessions,
-			}
-		}),
+		AddedTickets:     pie.Map(match.AddedTickets, ProtoTicketToMatchfunctionTicket),
 		ProposedTeams: pie.Map(match.ProposedTeams, func(team *BackfillProposal_Team) matchmaker.Team {
 			return matchmaker.Team{
 				TeamID: team.TeamId,
@@ -403,20 +363,28 @@ func MatchfunctionBackfillTicketToProtoBackfillTicket(backfillTicket matchmaker.
 	}
 }
 
+// PlayerDataToParties groups the players by their party, in the order the parties first appear.
+// A player without a party, such as a solo player, is listed as a party of one with an empty party ID.
 func PlayerDataToParties(players []playerdata.PlayerData) []matchmaker.Party {
-	mapParty := make(map[string][]string)
+	var parties []matchmaker.Party
+	partyIndex := make(map[string]int)
 
 	for _, player := range players {
-		mapParty[player.PartyID] = append(mapParty[player.PartyID], string(player.PlayerID))
-	}
+		if player.PartyID == "" {
+			parties = append(parties, matchmaker.Party{UserIDs: []string{playerdata.IDToString(player.PlayerID)}})
 
-	parties := make([]matchmaker.Party, 0, len(mapParty))
-	for partyID, userIDs := range mapParty {
-		parties = append(parties, matchmaker.Party{
-			PartyID: partyID,
-			UserIDs: userIDs,
-		})
+			continue
+		}
+
+		index, ok := partyIndex[player.PartyID]
+		if !ok {
+			index = len(parties)
+			partyIndex[player.PartyID] = index
+			parties = append(parties, matchmaker.Party{PartyID: player.PartyID})
+		}
+		parties[index].UserIDs = append(parties[index].UserIDs, playerdata.IDToString(player.PlayerID))
 	}
+
 	return parties
 }
 
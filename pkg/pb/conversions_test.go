@@ -0,0 +1,101 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package matchfunction
+
+import (
+	"fmt"
+	"testing"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+)
+
+func TestPlayerDataToParties(t *testing.T) {
+	player := func(id, partyID string) playerdata.PlayerData {
+		return playerdata.PlayerData{PlayerID: playerdata.ID(id), PartyID: partyID}
+	}
+
+	tests := []struct {
+		name    string
+		players []playerdata.PlayerData
+		parties []matchmaker.Party
+	}{
+		{
+			name:    "no players",
+			players: nil,
+			parties: nil,
+		},
+		{
+			name:    "solo players are parties of one",
+			players: []playerdata.PlayerData{player("a", ""), player("b", "")},
+			parties: []matchmaker.Party{{UserIDs: []string{"a"}}, {UserIDs: []string{"b"}}},
+		},
+		{
+			name:    "players of a party are grouped",
+			players: []playerdata.PlayerData{player("a", "p1"), player("b", "p1")},
+			parties: []matchmaker.Party{{PartyID: "p1", UserIDs: []string{"a", "b"}}},
+		},
+		{
+			name:    "parties in the order they first appear",
+			players: []playerdata.PlayerData{player("a", "p2"), player("b", ""), player("c", "p1"), player("d", "p2")},
+			parties: []matchmaker.Party{
+				{PartyID: "p2", UserIDs: []string{"a", "d"}},
+				{UserIDs: []string{"b"}},
+				{PartyID: "p1", UserIDs: []string{"c"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parties := PlayerDataToParties(tt.players)
+			if fmt.Sprint(parties) != fmt.Sprint(tt.parties) {
+				t.Errorf("expected parties %v, got %v", tt.parties, parties)
+			}
+		})
+	}
+}
+
+func TestProtoTicketPartyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name           string
+		partySessionID string
+		parties        []matchmaker.Party
+	}{
+		{
+			name:    "solo ticket",
+			parties: []matchmaker.Party{{UserIDs: []string{"a"}}},
+		},
+		{
+			name:           "party ticket",
+			partySessionID: "p1",
+			parties:        []matchmaker.Party{{PartyID: "p1", UserIDs: []string{"a", "b"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := matchmaker.Ticket{TicketID: "t1", PartySessionID: tt.partySessionID}
+			for _, party := range tt.parties {
+				for _, userID := range party.UserIDs {
+					ticket.Players = append(ticket.Players, playerdata.PlayerData{PlayerID: playerdata.ID(userID)})
+				}
+			}
+
+			converted := ProtoTicketToMatchfunctionTicket(MatchfunctionTicketToProtoTicket(ticket))
+			if converted.PartySessionID != tt.partySessionID {
+				t.Errorf("expected party session %q, got %q", tt.partySessionID, converted.PartySessionID)
+			}
+			for _, player := range converted.Players {
+				if player.PartyID != tt.partySessionID {
+					t.Errorf("expected player %s in party %q, got %q", player.PlayerID, tt.partySessionID, player.PartyID)
+				}
+			}
+			if parties := PlayerDataToParties(converted.Players); fmt.Sprint(parties) != fmt.Sprint(tt.parties) {
+				t.Errorf("expected parties %v, got %v", tt.parties, parties)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"cmp"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	matchfunction "matchmaking-function-grpc-plugin-server-go/pkg/pb"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+
+	pie_ "github.com/elliotchance/pie/v2"
+)
+
+const (
+	// BackfillFillPartial proposes the tickets that fit even when the session still has open slots
+	BackfillFillPartial = "partial"
+	// BackfillFillComplete only proposes tickets when they fill every open slot of the session
+	BackfillFillComplete = "complete"
+)
+
+// backfillSession tracks the teams of a session while tickets are being added to it
+type backfillSession struct {
+	rule         GameRules
+	teams        []matchmaker.Team
+	partialTeams int                 // number of teams of the partial match at the start of teams
+	tickets      []matchmaker.Ticket // tickets of the session, followed by the added tickets
+	added        int                 // number of added tickets at the end of tickets
+	region       string              // preferred region of the session, empty when unknown
+	skill        float64             // average skill of the players of the session before any ticket is added
+}
+
+// newBackfillSession copies the teams of the partial match of the backfill ticket, followed by empty teams up to the
+// maximum number of teams of the alliance rule, so tickets can be added without changing the partial match
+func newBackfillSession(backfillTicket matchmaker.BackfillTicket, rule GameRules) *backfillSession {
+	_, maxTeams, _, _ := rule.teamBounds()
+	partialMatch := backfillTicket.PartialMatch
+
+	teams := make([]matchmaker.Team, 0, max(len(partialMatch.Teams), maxTeams))
+	for _, team := range partialMatch.Teams {
+		teams = append(teams, matchmaker.Team{
+			UserIDs: slices.Clone(team.UserIDs),
+			Parties: slices.Clone(team.Parties),
+			TeamID:  team.TeamID,
+		})
+	}
+	for len(teams) < maxTeams {
+		teams = append(teams, matchmaker.Team{TeamID: common.GenerateUUID()})
+	}
+
+	session := &backfillSession{
+		rule:         rule,
+		teams:        teams,
+		partialTeams: len(partialMatch.Teams),
+		tickets:      slices.Clone(partialMatch.Tickets),
+	}
+	if len(partialMatch.RegionPreference) > 0 {
+		session.region = partialMatch.RegionPreference[0]
+	}
+	if attribute := rule.skillAttribute(); attribute != "" {
+		session.skill = ticketAttributeValue(matchmaker.Ticket{
+			Players: pie_.Flat(pie_.Map(partialMatch.Tickets, func(ticket matchmaker.Ticket) []playerdata.PlayerData {
+				return ticket.Players
+			})),
+		}, attribute, AggregationAverage)
+	}
+
+	return session
+}
+
+// openSlots returns the number of players each team can still take
+func (s *backfillSession) openSlots() []int {
+	_, _, _, maxPlayers := s.rule.teamBounds()
+
+	slots := make([]int, len(s.teams))
+	for i, team := range s.teams {
+		slots[i] = max(maxPlayers-len(team.UserIDs), 0)
+	}
+
+	return slots
+}
+
+// hasOpenSlot reports whether any team can take another player
+func (s *backfillSession) hasOpenSlot() bool {
+	return slices.ContainsFunc(s.openSlots(), func(slots int) bool { return slots > 0 })
+}
+
+// teamSkill returns the skill of the team according to the balance rule, from the players known to the session
+func (s *backfillSession) teamSkill(team matchmaker.Team) float64 {
+	var members []matchmaker.Ticket
+	for _, ticket := range s.tickets {
+		players := pie_.Filter(ticket.Players, func(player playerdata.PlayerData) bool {
+			return slices.Contains(team.UserIDs, player.PlayerID)
+		})
+		if len(players) > 0 {
+			members = append(members, matchmaker.Ticket{Players: players})
+		}
+	}
+
+	return s.rule.Balance.teamSkill(members)
+}
+
+// needsPlayers reports whether the team at the given index is below the minimum team size, either with players
+// already or as an empty team still needed to reach the minimum number of teams
+func (s *backfillSession) needsPlayers(index int) bool {
+	minTeams, _, minPlayers, _ := s.rule.teamBounds()
+	if len(s.teams[index].UserIDs) > 0 {
+		return len(s.teams[index].UserIDs) < minPlayers
+	}
+
+	teams := pie_.Filter(s.teams, func(team matchmaker.Team) bool { return len(team.UserIDs) > 0 })
+
+	return len(teams) < minTeams
+}
+
+// teamFor returns the index of the team to put the ticket on, or -1 when no team has room for all of its players.
+// Teams that need players to make the session valid are preferred, then teams with players over empty ones, then
+// the least skilled team when the rule balances the teams, then the team with the most room.
+func (s *backfillSession) teamFor(ticket matchmaker.Ticket) int {
+	if len(ticket.Players) == 0 {
+		return -1
+	}
+
+	slots := s.openSlots()
+	better := func(i int, target int) bool {
+		if needs, targetNeeds := s.needsPlayers(i), s.needsPlayers(target); needs != targetNeeds {
+			return needs
+		}
+		existing, targetExisting := len(s.teams[i].UserIDs) > 0, len(s.teams[target].UserIDs) > 0
+		if existing != targetExisting {
+			return existing
+		}
+		if s.rule.Balance.Attribute != "" {
+			if skill, targetSkill := s.teamSkill(s.teams[i]), s.teamSkill(s.teams[target]); skill != targetSkill {
+				return skill < targetSkill
+			}
+		}
+
+		return slots[i] > slots[target]
+	}
+
+	target := -1
+	for i := range s.teams {
+		if slots[i] < len(ticket.Players) {
+			continue
+		}
+		if target == -1 || better(i, target) {
+			target = i
+		}
+	}
+
+	return target
+}
+
+// accepts reports whether the ticket may join the session: the ticket did not exclude the session, no player of the
+// ticket is in conflict with the players of the session, and a team has room for all of its players
+func (s *backfillSession) accepts(ticket matchmaker.Ticket, sessionID string) bool {
+	if slices.Contains(ticket.ExcludedSessions, sessionID) {
+		return false
+	}
+
+	for _, other := range s.tickets {
+		if ticketsConflict(other, ticket, s.rule) {
+			return false
+		}
+	}
+
+	return s.teamFor(ticket) != -1
+}
+
+// add puts the players of the ticket on the team picked by teamFor, the ticket must be accepted by the session first
+func (s *backfillSession) add(ticket matchmaker.Ticket) {
+	target := s.teamFor(ticket)
+	s.teams[target].UserIDs = append(s.teams[target].UserIDs, pie_.Map(ticket.Players, playerdata.ToID)...)
+	s.teams[target].Parties = append(s.teams[target].Parties, matchfunction.PlayerDataToParties(ticket.Players)...)
+	s.tickets = append(s.tickets, ticket)
+	s.added++
+}
+
+// addedTickets returns the tickets added to the session
+func (s *backfillSession) addedTickets() []matchmaker.Ticket {
+	return s.tickets[len(s.tickets)-s.added:]
+}
+
+// proposedTeams returns the teams to propose to the session: every team of the partial match, even when still
+// empty, followed by the new teams that got players
+func (s *backfillSession) proposedTeams() []matchmaker.Team {
+	return append(slices.Clone(s.teams[:s.partialTeams]), pie_.Filter(s.teams[s.partialTeams:], func(team matchmaker.Team) bool {
+		return len(team.UserIDs) > 0
+	})...)
+}
+
+// acceptsLatency reports whether the ticket has an acceptable latency to the preferred region of the session.
+// Tickets without latencies, and sessions without a preferred region, accept any latency.
+func (s *backfillSession) acceptsLatency(ticket matchmaker.Ticket) bool {
+	if s.region == "" || len(ticket.Latencies) == 0 {
+		return true
+	}
+
+	latency, ok := ticket.Latencies[s.region]
+
+	return ok && (s.rule.RegionLatencyMaxMs == 0 || latency <= int64(s.rule.RegionLatencyMaxMs))
+}
+
+// skillDistance returns how far the average skill of the ticket is from the average skill of the session
+func (s *backfillSession) skillDistance(ticket matchmaker.Ticket) float64 {
+	attribute := s.rule.skillAttribute()
+	if attribute == "" {
+		return 0
+	}
+
+	return math.Abs(ticketAttributeValue(ticket, attribute, AggregationAverage) - s.skill)
+}
+
+// fitScore rates how well the ticket fits the session, from 1 for a ticket at the session's skill down towards 0
+func (s *backfillSession) fitScore(ticket matchmaker.Ticket) float64 {
+	return 1 / (1 + s.skillDistance(ticket))
+}
+
+// rankCandidates returns the indexes of the tickets with an acceptable latency to the session, from the best to the
+// worst candidate: closest in skill to the session first, then oldest first
+func (s *backfillSession) rankCandidates(tickets []matchmaker.Ticket) []int {
+	var candidates []int
+	for i, ticket := range tickets {
+		if s.acceptsLatency(ticket) {
+			candidates = append(candidates, i)
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b int) int {
+		if c := cmp.Compare(s.skillDistance(tickets[a]), s.skillDistance(tickets[b])); c != 0 {
+			return c
+		}
+
+		return tickets[a].CreatedAt.Compare(tickets[b].CreatedAt)
+	})
+
+	return candidates
+}
+
+// addedFitScore returns the average fit score of the added tickets
+func (s *backfillSession) addedFitScore() float64 {
+	added := s.addedTickets()
+	if len(added) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, ticket := range added {
+		total += s.fitScore(ticket)
+	}
+
+	return total / float64(len(added))
+}
+
+// proposeBackfill adds to the session of the backfill ticket as many candidate tickets as fit in its open slots, best
+// ranked candidates first. With the complete fill mode, it only proposes tickets that fill all the open slots.
+// It returns the proposal and the sorted indexes of the candidates it uses, without changing the candidates or the
+// backfill ticket, or false when the session gets no proposal.
+func proposeBackfill(log *slog.Logger, backfillTicket matchmaker.BackfillTicket, candidates []matchmaker.Ticket, rule GameRules) (matchmaker.BackfillProposal, []int, bool) {
+	session := newBackfillSession(backfillTicket, rule)
+
+	var used []int
+	for _, i := range session.rankCandidates(candidates) {
+		if !session.hasOpenSlot() {
+			break
+		}
+		if !session.accepts(candidates[i], backfillTicket.MatchSessionID) {
+			log.Info("skip backfilling ticket to session",
+				"ticketID", candidates[i].TicketID,
+				"sessionID", backfillTicket.MatchSessionID)
+
+			continue
+		}
+		session.add(candidates[i])
+		used = append(used, i)
+	}
+
+	if len(used) == 0 || (rule.BackfillFillMode == BackfillFillComplete && session.hasOpenSlot()) {
+		log.Info("not enough tickets to backfill the session",
+			"sessionID", backfillTicket.MatchSessionID,
+			"tickets", len(used))
+
+		return matchmaker.BackfillProposal{}, nil, false
+	}
+	slices.Sort(used)
+
+	attributes := maps.Clone(backfillTicket.PartialMatch.MatchAttributes)
+	if attributes == nil {
+		attributes = make(map[string]interface{})
+	}
+	attributes["generatedID"] = common.GenerateUUID()
+	attributes["fit_score"] = session.addedFitScore()
+
+	return matchmaker.BackfillProposal{
+		BackfillTicketID: backfillTicket.TicketID,
+		CreatedAt:        time.Time{},
+		AddedTickets:     slices.Clone(session.addedTickets()),
+		ProposedTeams:    session.proposedTeams(),
+		ProposalID:       common.GenerateUUID(),
+		MatchPool:        backfillTicket.MatchPool,
+		MatchSessionID:   backfillTicket.MatchSessionID,
+		Attributes:       attributes,
+	}, used, true
+}
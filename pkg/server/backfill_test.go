@@ -0,0 +1,483 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+)
+
+// testBackfillTicket returns a backfill ticket whose partial match has one team per entry of teams, named by team
+// ID, each holding the players of its tickets
+func testBackfillTicket(teams map[string][]matchmaker.Ticket, teamIDs ...string) matchmaker.BackfillTicket {
+	backfillTicket := matchmaker.BackfillTicket{TicketID: "backfill", MatchSessionID: "session", MatchPool: "pool"}
+	for _, teamID := range teamIDs {
+		team := matchmaker.Team{TeamID: teamID}
+		for _, ticket := range teams[teamID] {
+			for _, player := range ticket.Players {
+				team.UserIDs = append(team.UserIDs, player.PlayerID)
+			}
+			backfillTicket.PartialMatch.Tickets = append(backfillTicket.PartialMatch.Tickets, ticket)
+		}
+		backfillTicket.PartialMatch.Teams = append(backfillTicket.PartialMatch.Teams, team)
+	}
+
+	return backfillTicket
+}
+
+// teamMembers returns the teams of the proposal as "teamID:[userIDs]", with "new" as the ID of the teams that are
+// not in the partial match
+func teamMembers(proposal matchmaker.BackfillProposal, backfillTicket matchmaker.BackfillTicket) []string {
+	partialTeams := make(map[string]bool)
+	for _, team := range backfillTicket.PartialMatch.Teams {
+		partialTeams[team.TeamID] = true
+	}
+
+	var teams []string
+	for _, team := range proposal.ProposedTeams {
+		teamID := team.TeamID
+		if !partialTeams[teamID] {
+			teamID = "new"
+		}
+		teams = append(teams, fmt.Sprintf("%s:%v", teamID, team.UserIDs))
+	}
+
+	return teams
+}
+
+func TestProposeBackfillTeams(t *testing.T) {
+	now := time.Now()
+	alliance := func(minTeams, maxTeams, minPlayers, maxPlayers int) AllianceRule {
+		return AllianceRule{MinNumber: minTeams, MaxNumber: maxTeams, PlayerMinNumber: minPlayers, PlayerMaxNumber: maxPlayers}
+	}
+
+	tests := []struct {
+		name       string
+		rule       GameRules
+		teams      map[string][]matchmaker.Ticket
+		teamIDs    []string
+		candidates []matchmaker.Ticket
+		proposed   []string // nil when no proposal is made
+	}{
+		{
+			name:       "empty team of the partial match kept and filled first",
+			rule:       GameRules{AllianceRule: alliance(2, 2, 1, 3)},
+			teams:      map[string][]matchmaker.Ticket{"t1": {testTicket("a", now, 0)}},
+			teamIDs:    []string{"t1", "t2"},
+			candidates: []matchmaker.Ticket{testTicket("b", now, 0)},
+			proposed:   []string{"t1:[a-0]", "t2:[b-0]"},
+		},
+		{
+			name:       "valid teams topped up once the session is valid",
+			rule:       GameRules{AllianceRule: alliance(2, 2, 1, 3)},
+			teams:      map[string][]matchmaker.Ticket{"t1": {testTicket("a", now, 0)}},
+			teamIDs:    []string{"t1", "t2"},
+			candidates: []matchmaker.Ticket{testTicket("b", now.Add(-time.Minute), 0), testTicket("c", now, 0)},
+			proposed:   []string{"t1:[a-0 c-0]", "t2:[b-0]"},
+		},
+		{
+			name:       "empty team of the partial match kept when no ticket goes on it",
+			rule:       GameRules{AllianceRule: alliance(1, 2, 1, 3)},
+			teams:      map[string][]matchmaker.Ticket{"t1": {testTicket("a", now, 0)}},
+			teamIDs:    []string{"t1", "t2"},
+			candidates: []matchmaker.Ticket{testTicket("b", now, 0)},
+			proposed:   []string{"t1:[a-0 b-0]", "t2:[]"},
+		},
+		{
+			name: "team below the minimum size before the least skilled team",
+			rule: GameRules{AllianceRule: alliance(2, 2, 2, 3), Balance: BalanceRule{Attribute: "mmr"}},
+			teams: map[string][]matchmaker.Ticket{
+				"t1": {testTicket("a", now, 0, 0)},
+				"t2": {testTicket("b", now, 100)},
+			},
+			teamIDs:    []string{"t1", "t2"},
+			candidates: []matchmaker.Ticket{testTicket("c", now, 50)},
+			proposed:   []string{"t1:[a-0 a-1]", "t2:[b-0 c-0]"},
+		},
+		{
+			name:       "new team only proposed with players",
+			rule:       GameRules{AllianceRule: alliance(1, 3, 1, 3)},
+			teams:      map[string][]matchmaker.Ticket{"t1": {testTicket("a", now, 0, 0)}},
+			teamIDs:    []string{"t1"},
+			candidates: []matchmaker.Ticket{testTicket("b", now, 0, 0)},
+			proposed:   []string{"t1:[a-0 a-1]", "new:[b-0 b-1]"},
+		},
+		{
+			name:       "full session",
+			rule:       GameRules{AllianceRule: alliance(2, 2, 1, 1)},
+			teams:      map[string][]matchmaker.Ticket{"t1": {testTicket("a", now, 0)}, "t2": {testTicket("b", now, 0)}},
+			teamIDs:    []string{"t1", "t2"},
+			candidates: []matchmaker.Ticket{testTicket("c", now, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backfillTicket := testBackfillTicket(tt.teams, tt.teamIDs...)
+
+			proposal, _, ok := proposeBackfill(testScope().Log, backfillTicket, tt.candidates, tt.rule)
+			if ok != (tt.proposed != nil) {
+				t.Fatalf("expected a proposal %v, got %v", tt.proposed != nil, ok)
+			}
+			if teams := teamMembers(proposal, backfillTicket); ok && fmt.Sprint(teams) != fmt.Sprint(tt.proposed) {
+				t.Errorf("expected proposed teams %v, got %v", tt.proposed, teams)
+			}
+		})
+	}
+}
+
+func TestProposeBackfillFillMode(t *testing.T) {
+	now := time.Now()
+	teams := map[string][]matchmaker.Ticket{"t1": {testTicket("a", now, 0)}, "t2": {testTicket("b", now, 0)}}
+
+	tests := []struct {
+		name       string
+		fillMode   string
+		candidates []matchmaker.Ticket
+		added      []string // nil when no proposal is made
+	}{
+		{
+			name:       "partial fill with one ticket",
+			candidates: []matchmaker.Ticket{testTicket("c", now, 0)},
+			added:      []string{"c"},
+		},
+		{
+			name:       "partial fill with several tickets",
+			fillMode:   BackfillFillPartial,
+			candidates: []matchmaker.Ticket{testTicket("c", now, 0), testTicket("d", now, 0)},
+			added:      []string{"c", "d"},
+		},
+		{
+			name:       "no more tickets than open slots",
+			candidates: []matchmaker.Ticket{testTicket("c", now, 0), testTicket("d", now, 0), testTicket("e", now, 0)},
+			added:      []string{"c", "d"},
+		},
+		{
+			name:       "party split over two teams",
+			candidates: []matchmaker.Ticket{testTicket("c", now, 0, 0)},
+		},
+		{
+			name:       "complete fill with too few tickets",
+			fillMode:   BackfillFillComplete,
+			candidates: []matchmaker.Ticket{testTicket("c", now, 0)},
+		},
+		{
+			name:       "complete fill",
+			fillMode:   BackfillFillComplete,
+			candidates: []matchmaker.Ticket{testTicket("c", now, 0), testTicket("d", now, 0)},
+			added:      []string{"c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := GameRules{
+				AllianceRule:     AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 1, PlayerMaxNumber: 2},
+				BackfillFillMode: tt.fillMode,
+			}
+
+			proposal, used, ok := proposeBackfill(testScope().Log, testBackfillTicket(teams, "t1", "t2"), tt.candidates, rule)
+			if ok != (tt.added != nil) {
+				t.Fatalf("expected a proposal %v, got %v", tt.added != nil, ok)
+			}
+			if !ok {
+				return
+			}
+
+			var added []string
+			for _, ticket := range proposal.AddedTickets {
+				added = append(added, ticket.TicketID)
+			}
+			if fmt.Sprint(added) != fmt.Sprint(tt.added) {
+				t.Errorf("expected added tickets %v, got %v", tt.added, added)
+			}
+			if len(used) != len(tt.added) {
+				t.Errorf("expected %d candidates used, got %v", len(tt.added), used)
+			}
+		})
+	}
+}
+
+func TestRulesFromJSONBackfillFillMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "default", rules: `{}`},
+		{name: "partial", rules: `{"backfill_fill_mode": "partial"}`},
+		{name: "complete", rules: `{"backfill_fill_mode": "complete"}`},
+		{name: "unknown", rules: `{"backfill_fill_mode": "all"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MatchMaker{}.RulesFromJSON(testScope(), tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRankCandidates(t *testing.T) {
+	now := time.Now()
+	ticket := func(id string, age time.Duration, mmr float64, latencies map[string]int64) matchmaker.Ticket {
+		ticket := testTicket(id, now.Add(-age), mmr)
+		ticket.Latencies = latencies
+
+		return ticket
+	}
+
+	tests := []struct {
+		name       string
+		rule       GameRules
+		candidates []matchmaker.Ticket
+		ranked     []string
+	}{
+		{
+			name:       "oldest first without a skill attribute",
+			candidates: []matchmaker.Ticket{ticket("a", time.Second, 0, nil), ticket("b", time.Minute, 50, nil), ticket("c", time.Hour, 100, nil)},
+			ranked:     []string{"c", "b", "a"},
+		},
+		{
+			name:       "closest in skill first",
+			rule:       GameRules{MatchingRule: []MatchingRule{{Attribute: "mmr", Distance: 100}}},
+			candidates: []matchmaker.Ticket{ticket("a", time.Hour, 0, nil), ticket("b", time.Second, 90, nil), ticket("c", time.Minute, 120, nil)},
+			ranked:     []string{"b", "c", "a"},
+		},
+		{
+			name:       "balance attribute before the matching rule",
+			rule:       GameRules{MatchingRule: []MatchingRule{{Attribute: "rank", Distance: 100}}, Balance: BalanceRule{Attribute: "mmr"}},
+			candidates: []matchmaker.Ticket{ticket("a", time.Hour, 0, nil), ticket("b", time.Second, 90, nil)},
+			ranked:     []string{"b", "a"},
+		},
+		{
+			name:       "oldest first at the same skill",
+			rule:       GameRules{Balance: BalanceRule{Attribute: "mmr"}},
+			candidates: []matchmaker.Ticket{ticket("a", time.Second, 110, nil), ticket("b", time.Minute, 90, nil)},
+			ranked:     []string{"b", "a"},
+		},
+		{
+			name:       "candidates over the latency ceiling left out",
+			rule:       GameRules{RegionLatencyMaxMs: 100},
+			candidates: []matchmaker.Ticket{ticket("a", time.Hour, 0, map[string]int64{"us": 150}), ticket("b", time.Second, 0, map[string]int64{"us": 50})},
+			ranked:     []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backfillTicket := testBackfillTicket(map[string][]matchmaker.Ticket{"t1": {testTicket("s", now, 100)}}, "t1")
+			backfillTicket.PartialMatch.RegionPreference = []string{"us"}
+			session := newBackfillSession(backfillTicket, tt.rule)
+
+			var ranked []string
+			for _, i := range session.rankCandidates(tt.candidates) {
+				ranked = append(ranked, tt.candidates[i].TicketID)
+			}
+			if fmt.Sprint(ranked) != fmt.Sprint(tt.ranked) {
+				t.Errorf("expected candidates ranked %v, got %v", tt.ranked, ranked)
+			}
+		})
+	}
+}
+
+func TestAcceptsLatency(t *testing.T) {
+	tests := []struct {
+		name       string
+		region     string
+		maxLatency int
+		latencies  map[string]int64
+		accepted   bool
+	}{
+		{name: "within the ceiling", region: "us", maxLatency: 100, latencies: map[string]int64{"us": 50}, accepted: true},
+		{name: "at the ceiling", region: "us", maxLatency: 100, latencies: map[string]int64{"us": 100}, accepted: true},
+		{name: "over the ceiling", region: "us", maxLatency: 100, latencies: map[string]int64{"us": 150, "eu": 10}},
+		{name: "no latency to the region", region: "us", maxLatency: 100, latencies: map[string]int64{"eu": 10}},
+		{name: "no latency to the region without a ceiling", region: "us", latencies: map[string]int64{"eu": 10}},
+		{name: "any latency without a ceiling", region: "us", latencies: map[string]int64{"us": 500}, accepted: true},
+		{name: "ticket without latencies", region: "us", maxLatency: 100, accepted: true},
+		{name: "session without a region", maxLatency: 100, latencies: map[string]int64{"us": 500}, accepted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backfillTicket := testBackfillTicket(nil)
+			if tt.region != "" {
+				backfillTicket.PartialMatch.RegionPreference = []string{tt.region, "eu"}
+			}
+			session := newBackfillSession(backfillTicket, GameRules{RegionLatencyMaxMs: tt.maxLatency})
+
+			if accepted := session.acceptsLatency(latencyTicket("a", tt.latencies)); accepted != tt.accepted {
+				t.Errorf("expected the latency accepted %v, got %v", tt.accepted, accepted)
+			}
+		})
+	}
+}
+
+func TestProposeBackfillFitScore(t *testing.T) {
+	now := time.Now()
+	rule := GameRules{
+		AllianceRule: AllianceRule{MinNumber: 1, MaxNumber: 1, PlayerMinNumber: 1, PlayerMaxNumber: 3},
+		Balance:      BalanceRule{Attribute: "mmr"},
+	}
+
+	tests := []struct {
+		name       string
+		candidates []matchmaker.Ticket
+		fitScore   float64
+	}{
+		{name: "at the session skill", candidates: []matchmaker.Ticket{testTicket("a", now, 10)}, fitScore: 1},
+		{name: "away from the session skill", candidates: []matchmaker.Ticket{testTicket("a", now, 13)}, fitScore: 0.25},
+		{name: "party average", candidates: []matchmaker.Ticket{testTicket("a", now, 0, 14)}, fitScore: 0.25},
+		{name: "average over the tickets", candidates: []matchmaker.Ticket{testTicket("a", now, 10), testTicket("b", now, 13)}, fitScore: 0.625},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backfillTicket := testBackfillTicket(map[string][]matchmaker.Ticket{"t1": {testTicket("s", now, 10)}}, "t1")
+
+			proposal, _, ok := proposeBackfill(testScope().Log, backfillTicket, tt.candidates, rule)
+			if !ok {
+				t.Fatal("expected a proposal")
+			}
+			if proposal.Attributes["fit_score"] != tt.fitScore {
+				t.Errorf("expected a fit score of %v, got %v", tt.fitScore, proposal.Attributes["fit_score"])
+			}
+		})
+	}
+}
+
+func TestProposeBackfillKeepsInputs(t *testing.T) {
+	now := time.Now()
+	rule := GameRules{AllianceRule: AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 1, PlayerMaxNumber: 3}}
+
+	backfillTicket := testBackfillTicket(map[string][]matchmaker.Ticket{"t1": {testTicket("a", now, 0)}}, "t1", "t2")
+	backfillTicket.PartialMatch.MatchAttributes = map[string]interface{}{"mode": "ranked"}
+	backfillTicket.PartialMatch.Teams[0].UserIDs = append(make([]playerdata.ID, 0, 3), backfillTicket.PartialMatch.Teams[0].UserIDs...)
+	candidates := []matchmaker.Ticket{testTicket("b", now, 0), testTicket("c", now, 0)}
+
+	before := fmt.Sprintf("%v %v", backfillTicket, candidates)
+	proposal, _, ok := proposeBackfill(testScope().Log, backfillTicket, candidates, rule)
+	if !ok {
+		t.Fatal("expected a proposal")
+	}
+
+	if after := fmt.Sprintf("%v %v", backfillTicket, candidates); after != before {
+		t.Errorf("expected the backfill ticket and candidates unchanged, got %s, was %s", after, before)
+	}
+	if team := backfillTicket.PartialMatch.Teams[0].UserIDs[:2]; team[1] != "" {
+		t.Errorf("expected the partial match team not to be appended to, got %v", team)
+	}
+	if proposal.Attributes["mode"] != "ranked" || proposal.Attributes["generatedID"] == nil {
+		t.Errorf("expected the partial match attributes and a generated ID in the proposal, got %v", proposal.Attributes)
+	}
+}
+
+func TestBuildBackfillMatchKeepsTickets(t *testing.T) {
+	now := time.Now()
+	rule := GameRules{AllianceRule: AllianceRule{MinNumber: 1, MaxNumber: 1, PlayerMinNumber: 1, PlayerMaxNumber: 2}}
+	backfillTicket := func(id string, players int) matchmaker.BackfillTicket {
+		backfillTicket := testBackfillTicket(map[string][]matchmaker.Ticket{"t1": {testTicket("s"+id, now, make([]float64, players)...)}}, "t1")
+		backfillTicket.TicketID = id
+		backfillTicket.MatchSessionID = "session-" + id
+
+		return backfillTicket
+	}
+	excluding := func(ticket matchmaker.Ticket, sessionIDs ...string) matchmaker.Ticket {
+		ticket.ExcludedSessions = sessionIDs
+
+		return ticket
+	}
+
+	tests := []struct {
+		name            string
+		tickets         []matchmaker.Ticket
+		backfillTickets []matchmaker.BackfillTicket
+		proposals       []string // backfill ticket and added tickets of each proposal
+		remaining       []string
+		remainingFill   []string
+	}{
+		{
+			name:            "unused candidates stay in the pool",
+			tickets:         []matchmaker.Ticket{testTicket("a", now.Add(-time.Minute), 0), testTicket("b", now, 0)},
+			backfillTickets: []matchmaker.BackfillTicket{backfillTicket("1", 1)},
+			proposals:       []string{"1:[a]"},
+			remaining:       []string{"b"},
+		},
+		{
+			name:            "ticket skipped by one session offered to the next",
+			tickets:         []matchmaker.Ticket{excluding(testTicket("a", now.Add(-time.Minute), 0), "session-1"), testTicket("b", now, 0)},
+			backfillTickets: []matchmaker.BackfillTicket{backfillTicket("1", 1), backfillTicket("2", 1)},
+			proposals:       []string{"1:[b]", "2:[a]"},
+		},
+		{
+			name:            "full session keeps its backfill ticket",
+			tickets:         []matchmaker.Ticket{testTicket("a", now, 0)},
+			backfillTickets: []matchmaker.BackfillTicket{backfillTicket("1", 2), backfillTicket("2", 1)},
+			proposals:       []string{"2:[a]"},
+			remainingFill:   []string{"1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(chan matchmaker.BackfillProposal, len(tt.backfillTickets))
+			remaining, remainingFill := buildBackfillMatch(testScope(), tt.tickets, tt.backfillTickets, rule, results)
+			close(results)
+
+			var proposals []string
+			for proposal := range results {
+				var added []string
+				for _, ticket := range proposal.AddedTickets {
+					added = append(added, ticket.TicketID)
+				}
+				proposals = append(proposals, fmt.Sprintf("%s:%v", proposal.BackfillTicketID, added))
+			}
+			if fmt.Sprint(proposals) != fmt.Sprint(tt.proposals) {
+				t.Errorf("expected proposals %v, got %v", tt.proposals, proposals)
+			}
+
+			var ticketIDs, backfillTicketIDs []string
+			for _, ticket := range remaining {
+				ticketIDs = append(ticketIDs, ticket.TicketID)
+			}
+			for _, ticket := range remainingFill {
+				backfillTicketIDs = append(backfillTicketIDs, ticket.TicketID)
+			}
+			if fmt.Sprint(ticketIDs) != fmt.Sprint(tt.remaining) {
+				t.Errorf("expected remaining tickets %v, got %v", tt.remaining, ticketIDs)
+			}
+			if fmt.Sprint(backfillTicketIDs) != fmt.Sprint(tt.remainingFill) {
+				t.Errorf("expected remaining backfill tickets %v, got %v", tt.remainingFill, backfillTicketIDs)
+			}
+		})
+	}
+}
+
+func TestBuildBackfillMatchCancelled(t *testing.T) {
+	now := time.Now()
+	rule := GameRules{AllianceRule: AllianceRule{MinNumber: 1, MaxNumber: 1, PlayerMinNumber: 1, PlayerMaxNumber: 2}}
+
+	scope := testScope()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scope.Ctx = ctx
+
+	// nobody reads the results, the proposal can only be dropped
+	results := make(chan matchmaker.BackfillProposal)
+	backfillTickets := []matchmaker.BackfillTicket{testBackfillTicket(map[string][]matchmaker.Ticket{"t1": {testTicket("s", now, 0)}}, "t1")}
+	remaining, remainingFill := buildBackfillMatch(scope, []matchmaker.Ticket{testTicket("a", now, 0)}, backfillTickets, rule, results)
+
+	if len(remaining) != 1 || len(remainingFill) != 1 {
+		t.Errorf("expected the tickets of the dropped proposal to remain, got %v and %v", remaining, remainingFill)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+const (
+	// BalanceAuto searches exhaustively when the match is small enough and locally otherwise
+	BalanceAuto = "auto"
+	// BalanceSnake drafts the tickets from the most to the least skilled, in snake order over the teams
+	BalanceSnake = "snake"
+	// BalanceExhaustive tries every placement of the tickets, for small matches only
+	BalanceExhaustive = "exhaustive"
+	// BalanceLocalSearch moves and swaps tickets between teams while the balance improves
+	BalanceLocalSearch = "local_search"
+
+	AggregationSum = "sum"
+
+	// exhaustiveMaxPlacements caps the number of placements the exhaustive search is allowed to try
+	exhaustiveMaxPlacements = 100000
+	// localSearchMaxRounds caps the number of improvements the local search makes
+	localSearchMaxRounds = 100
+)
+
+// BalanceRule distributes the tickets across the teams to even out the teams' skill
+type BalanceRule struct {
+	Attribute   string `json:"attribute"`   // numeric player attribute holding the skill, no balancing when empty
+	Aggregation string `json:"aggregation"` // sum (default) or avg of the players' skill for a team
+	Strategy    string `json:"strategy"`    // auto (default), snake, exhaustive or local_search
+}
+
+// teamSkill returns the aggregated skill of the team
+func (r BalanceRule) teamSkill(team []matchmaker.Ticket) float64 {
+	var total float64
+	for _, ticket := range team {
+		total += ticketAttributeValue(ticket, r.Attribute, AggregationAverage) * float64(len(ticket.Players))
+	}
+
+	if r.Aggregation == AggregationAverage {
+		if count := ticketPlayerCount(team); count > 0 {
+			return total / float64(count)
+		}
+
+		return 0
+	}
+
+	return total
+}
+
+// spread returns the difference between the most and the least skilled teams
+func (r BalanceRule) spread(teams ticketTeams) float64 {
+	highest, lowest := math.Inf(-1), math.Inf(1)
+	for _, team := range teams {
+		skill := r.teamSkill(team)
+		highest = max(highest, skill)
+		lowest = min(lowest, skill)
+	}
+
+	return highest - lowest
+}
+
+// teamLimits bounds the number of players and the role composition of every team of a match
+type teamLimits struct {
+	minPlayers int
+	maxPlayers int
+	roles      RoleRule
+}
+
+// fits reports whether the team is within the maximum size and can still reach the role composition
+func (l teamLimits) fits(team []matchmaker.Ticket) bool {
+	return ticketPlayerCount(team) <= l.maxPlayers && l.roles.canComplete(team, l.maxPlayers)
+}
+
+// allows reports whether every team is within the size limits and has its role composition
+func (l teamLimits) allows(teams ticketTeams) bool {
+	for i := range teams {
+		if count := teams.playerCount(i); count < l.minPlayers || count > l.maxPlayers {
+			return false
+		}
+		if !l.roles.isComplete(teams[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// balanceTeams redistributes the tickets of the teams, keeping each ticket whole, to minimize the skill spread of
+// the teams. Without a balance attribute, the tickets are only redistributed when the teams break the limits.
+// It returns the teams unchanged when no better placement is found.
+func balanceTeams(teams ticketTeams, rule BalanceRule, limits teamLimits) ticketTeams {
+	var tickets []matchmaker.Ticket
+	for _, team := range teams {
+		tickets = append(tickets, team...)
+	}
+
+	if rule.Attribute == "" {
+		if limits.allows(teams) {
+			return teams
+		}
+		if placed := exhaustiveSearch(tickets, len(teams), rule, limits); placed != nil {
+			return placed
+		}
+
+		return teams
+	}
+
+	if len(teams) < 2 {
+		return teams
+	}
+
+	strategy := rule.Strategy
+	if strategy == "" || strategy == BalanceAuto {
+		strategy = BalanceLocalSearch
+		if math.Pow(float64(len(teams)), float64(len(tickets)-1)) <= exhaustiveMaxPlacements {
+			strategy = BalanceExhaustive
+		}
+	}
+
+	var balanced ticketTeams
+	switch strategy {
+	case BalanceSnake:
+		balanced = snakeDraft(tickets, len(teams), rule, limits)
+	case BalanceExhaustive:
+		balanced = exhaustiveSearch(tickets, len(teams), rule, limits)
+	default:
+		start := snakeDraft(tickets, len(teams), rule, limits)
+		if start == nil {
+			start = teams
+		}
+		balanced = localSearch(start, rule, limits)
+	}
+
+	if balanced == nil || (limits.allows(teams) && rule.spread(balanced) > rule.spread(teams)) {
+		return teams
+	}
+
+	return balanced
+}
+
+// snakeDraft places the tickets from the most to the least skilled onto the teams in snake order (1, 2, 2, 1, ...),
+// skipping teams without room. It returns nil when the draft breaks the team limits.
+func snakeDraft(tickets []matchmaker.Ticket, numTeams int, rule BalanceRule, limits teamLimits) ticketTeams {
+	sorted := slices.Clone(tickets)
+	slices.SortStableFunc(sorted, func(a, b matchmaker.Ticket) int {
+		return cmp.Compare(rule.teamSkill([]matchmaker.Ticket{b}), rule.teamSkill([]matchmaker.Ticket{a}))
+	})
+
+	teams := make(ticketTeams, numTeams)
+	turn := 0
+	for _, ticket := range sorted {
+		placed := false
+		for attempt := 0; attempt < 2*numTeams && !placed; attempt++ {
+			round, index := (turn+attempt)/numTeams, (turn+attempt)%numTeams
+			if round%2 == 1 {
+				index = numTeams - 1 - index
+			}
+			if limits.fits(append(slices.Clone(teams[index]), ticket)) {
+				teams[index] = append(teams[index], ticket)
+				placed = true
+			}
+		}
+		if !placed {
+			return nil
+		}
+		turn++
+	}
+
+	if !limits.allows(teams) {
+		return nil
+	}
+
+	return teams
+}
+
+// exhaustiveSearch tries every placement of the tickets onto numTeams teams and returns the one with the lowest
+// skill spread, or nil when no placement is within the team limits
+func exhaustiveSearch(tickets []matchmaker.Ticket, numTeams int, rule BalanceRule, limits teamLimits) ticketTeams {
+	var best ticketTeams
+	bestSpread := math.Inf(1)
+	placements := 0
+
+	teams := make(ticketTeams, numTeams)
+	var place func(index int)
+	place = func(index int) {
+		if placements >= exhaustiveMaxPlacements || bestSpread == 0 {
+			return
+		}
+		if index == len(tickets) {
+			placements++
+			if spread := rule.spread(teams); limits.allows(teams) && spread < bestSpread {
+				bestSpread = spread
+				best = cloneTeams(teams)
+			}
+
+			return
+		}
+
+		for team := range teams {
+			// teams are interchangeable, so the first ticket only needs to be tried on the first team
+			if index == 0 && team > 0 {
+				break
+			}
+			if !limits.fits(append(slices.Clone(teams[team]), tickets[index])) {
+				continue
+			}
+			teams[team] = append(teams[team], tickets[index])
+			place(index + 1)
+			teams[team] = teams[team][:len(teams[team])-1]
+		}
+	}
+	place(0)
+
+	return best
+}
+
+// localSearch improves the teams one change at a time while a change lowers the skill spread
+func localSearch(teams ticketTeams, rule BalanceRule, limits teamLimits) ticketTeams {
+	current := teams
+	for round := 0; round < localSearchMaxRounds; round++ {
+		better := improveTeams(current, rule, limits)
+		if better == nil {
+			break
+		}
+		current = better
+	}
+
+	return current
+}
+
+// improveTeams returns the first move of a ticket to another team, or swap of two tickets of different teams, that
+// lowers the skill spread and keeps the teams within the limits. It returns nil when there is no such change.
+func improveTeams(teams ticketTeams, rule BalanceRule, limits teamLimits) ticketTeams {
+	spread := rule.spread(teams)
+	for from := range teams {
+		for i := range teams[from] {
+			for to := range teams {
+				if to == from {
+					continue
+				}
+
+				candidates := []ticketTeams{moveTicket(teams, from, i, to)}
+				for j := range teams[to] {
+					candidates = append(candidates, swapTickets(teams, from, i, to, j))
+				}
+
+				for _, candidate := range candidates {
+					if rule.spread(candidate) < spread && limits.allows(candidate) {
+						return candidate
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// moveTicket returns a copy of the teams with the ticket at index i of team from moved onto team to
+func moveTicket(teams ticketTeams, from int, i int, to int) ticketTeams {
+	moved := cloneTeams(teams)
+	moved[to] = append(moved[to], moved[from][i])
+	moved[from] = slices.Delete(moved[from], i, i+1)
+
+	return moved
+}
+
+// swapTickets returns a copy of the teams with the ticket at index i of team from and at index j of team to swapped
+func swapTickets(teams ticketTeams, from int, i int, to int, j int) ticketTeams {
+	swapped := cloneTeams(teams)
+	swapped[from][i], swapped[to][j] = swapped[to][j], swapped[from][i]
+
+	return swapped
+}
+
+// cloneTeams returns a copy of the teams that can be modified without affecting the original
+func cloneTeams(teams ticketTeams) ticketTeams {
+	cloned := make(ticketTeams, len(teams))
+	for i, team := range teams {
+		cloned[i] = slices.Clone(team)
+	}
+
+	return cloned
+}
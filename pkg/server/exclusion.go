@@ -0,0 +1,99 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"slices"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+)
+
+// defaultBlockedPlayersAttribute is the player attribute holding the IDs of the players they blocked
+const defaultBlockedPlayersAttribute = "blocked_players"
+
+// defaultPreviousSessionsAttribute is the player attribute holding the IDs of the game sessions they were matched into
+const defaultPreviousSessionsAttribute = "previous_sessions"
+
+// blockedPlayersAttribute returns the player attribute holding the block list
+func (r GameRules) blockedPlayersAttribute() string {
+	if r.BlockedPlayersAttribute == "" {
+		return defaultBlockedPlayersAttribute
+	}
+
+	return r.BlockedPlayersAttribute
+}
+
+// previousSessionsAttribute returns the player attribute holding the previous game sessions
+func (r GameRules) previousSessionsAttribute() string {
+	if r.PreviousSessionsAttribute == "" {
+		return defaultPreviousSessionsAttribute
+	}
+
+	return r.PreviousSessionsAttribute
+}
+
+// playerStrings returns the strings listed in the player attribute
+func playerStrings(player playerdata.PlayerData, attribute string) []string {
+	switch value := player.Attributes[attribute].(type) {
+	case []string:
+		return value
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
+
+// blocks reports whether a player of ticket a blocked a player of ticket b
+func blocks(a matchmaker.Ticket, b matchmaker.Ticket, attribute string) bool {
+	for _, player := range a.Players {
+		blocked := playerStrings(player, attribute)
+		if len(blocked) == 0 {
+			continue
+		}
+		for _, other := range b.Players {
+			if slices.Contains(blocked, playerdata.IDToString(other.PlayerID)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// excludes reports whether ticket a excluded a game session a player of ticket b was previously matched into.
+// ExcludedSessions names game sessions, so it is compared with the previous sessions listed in the player attribute,
+// never with the party session of the ticket. Backfill compares it with the session of the backfill ticket instead.
+func excludes(a matchmaker.Ticket, b matchmaker.Ticket, attribute string) bool {
+	if len(a.ExcludedSessions) == 0 {
+		return false
+	}
+
+	for _, player := range b.Players {
+		for _, session := range playerStrings(player, attribute) {
+			if slices.Contains(a.ExcludedSessions, session) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ticketsConflict reports whether the two tickets must be kept out of the same match, because a player of either
+// blocked a player of the other or either excluded a session a player of the other was previously matched into
+func ticketsConflict(a matchmaker.Ticket, b matchmaker.Ticket, rule GameRules) bool {
+	blocked, previous := rule.blockedPlayersAttribute(), rule.previousSessionsAttribute()
+
+	return blocks(a, b, blocked) || blocks(b, a, blocked) || excludes(a, b, previous) || excludes(b, a, previous)
+}
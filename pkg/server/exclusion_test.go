@@ -0,0 +1,116 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+func TestTicketsConflict(t *testing.T) {
+	now := time.Now()
+	withAttribute := func(ticket matchmaker.Ticket, attribute string, values ...interface{}) matchmaker.Ticket {
+		ticket.Players[0].Attributes[attribute] = values
+
+		return ticket
+	}
+	excluding := func(ticket matchmaker.Ticket, sessions ...string) matchmaker.Ticket {
+		ticket.ExcludedSessions = sessions
+
+		return ticket
+	}
+
+	tests := []struct {
+		name     string
+		rule     GameRules
+		a        matchmaker.Ticket
+		b        matchmaker.Ticket
+		conflict bool
+	}{
+		{
+			name: "no conflict",
+			a:    testTicket("a", now, 0),
+			b:    testTicket("b", now, 0),
+		},
+		{
+			name:     "blocked player",
+			a:        withAttribute(testTicket("a", now, 0), "blocked_players", "b-0"),
+			b:        testTicket("b", now, 0),
+			conflict: true,
+		},
+		{
+			name:     "excluded previous session",
+			a:        excluding(testTicket("a", now, 0), "session-1"),
+			b:        withAttribute(testTicket("b", now, 0), "previous_sessions", "session-1"),
+			conflict: true,
+		},
+		{
+			name:     "excluded previous session the other way",
+			a:        withAttribute(testTicket("a", now, 0), "previous_sessions", "session-1"),
+			b:        excluding(testTicket("b", now, 0), "session-1"),
+			conflict: true,
+		},
+		{
+			name: "other previous session",
+			a:    excluding(testTicket("a", now, 0), "session-1"),
+			b:    withAttribute(testTicket("b", now, 0), "previous_sessions", "session-2"),
+		},
+		{
+			name: "party session is not a game session",
+			a:    excluding(testTicket("a", now, 0), "party-1"),
+			b:    matchmaker.Ticket{PartySessionID: "party-1", Players: testTicket("b", now, 0).Players},
+		},
+		{
+			name:     "custom previous sessions attribute",
+			rule:     GameRules{PreviousSessionsAttribute: "last_games"},
+			a:        excluding(testTicket("a", now, 0), "session-1"),
+			b:        withAttribute(testTicket("b", now, 0), "last_games", "session-1"),
+			conflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if conflict := ticketsConflict(tt.a, tt.b, tt.rule); conflict != tt.conflict {
+				t.Errorf("expected a conflict %v, got %v", tt.conflict, conflict)
+			}
+		})
+	}
+}
+
+func TestBuildMatchExcludedSession(t *testing.T) {
+	now := time.Now()
+	rule := GameRules{AllianceRule: AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 1, PlayerMaxNumber: 1}}
+
+	reporter := testTicket("reporter", now.Add(-time.Minute), 0)
+	reporter.ExcludedSessions = []string{"session-1"}
+	griefer := testTicket("griefer", now.Add(-time.Second), 0)
+	griefer.Players[0].Attributes["previous_sessions"] = []interface{}{"session-1"}
+
+	results := make(chan matchmaker.Match, 1)
+	unmatched := buildMatch(testScope(), reporter, nil, rule, results)
+	unmatched = buildMatch(testScope(), griefer, unmatched, rule, results)
+	if len(results) != 0 {
+		t.Fatal("expected the tickets that exclude each other not to be matched")
+	}
+
+	unmatched = buildMatch(testScope(), testTicket("other", now, 0), unmatched, rule, results)
+	close(results)
+
+	match := <-results
+	if len(match.Tickets) != 2 || match.Tickets[0].TicketID == match.Tickets[1].TicketID {
+		t.Fatalf("expected a match of two tickets, got %v", match.Tickets)
+	}
+	for _, ticket := range match.Tickets {
+		if ticket.TicketID != "reporter" && ticket.TicketID != "other" {
+			t.Errorf("expected the reporter to be matched with the other ticket, got %s", ticket.TicketID)
+		}
+	}
+	if len(unmatched) != 1 || unmatched[0].TicketID != "griefer" {
+		t.Errorf("expected the griefer to wait, got %v", unmatched)
+	}
+}
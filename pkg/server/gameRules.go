@@ -4,6 +4,16 @@
 
 package server
 
+import (
+	"math"
+	"slices"
+)
+
+const (
+	defaultTeamNumber   = 1
+	defaultPlayerNumber = 2
+)
+
 type AllianceRule struct {
 	MinNumber       int `json:"min_number" valid:"range(0|2147483647)"`
 	MaxNumber       int `json:"max_number" valid:"range(0|2147483647)"`
@@ -11,9 +21,114 @@ type AllianceRule struct {
 	PlayerMaxNumber int `json:"player_max_number" valid:"range(0|2147483647)"`
 }
 
+// TicketValidationRule lists the extra checks a ticket must pass to be queued
+type TicketValidationRule struct {
+	RequiredAttributes []string `json:"required_attributes"` // player attributes every player of the ticket must have
+}
+
 type GameRules struct {
-	ShipCountMin int          `json:"shipCountMin"`
-	ShipCountMax int          `json:"shipCountMax"`
-	AutoBackfill bool         `json:"auto_backfill"`
-	AllianceRule AllianceRule `json:"alliance"`
+	ShipCountMin       int          `json:"shipCountMin"`
+	ShipCountMax       int          `json:"shipCountMax"`
+	AutoBackfill       bool         `json:"auto_backfill"`
+	BackfillFillMode   string       `json:"backfill_fill_mode"`
+	AllianceRule       AllianceRule `json:"alliance"`
+	RegionLatencyMaxMs int          `json:"region_latency_max_ms" valid:"range(0|2147483647)"`
+	RegionSelection    string       `json:"region_selection"`
+
+	MatchingRule []MatchingRule   `json:"matching_rule"`
+	Relaxation   []RelaxationRule `json:"relaxation"`
+	Balance      BalanceRule      `json:"balance"`
+	Role         RoleRule         `json:"role"`
+
+	BlockedPlayersAttribute   string               `json:"blocked_players_attribute"`   // player attribute with the IDs of blocked players
+	PreviousSessionsAttribute string               `json:"previous_sessions_attribute"` // player attribute with the IDs of previous game sessions
+	TicketValidation          TicketValidationRule `json:"ticket_validation"`
+}
+
+// TeamBounds returns the bounds of the number of teams and of the players of a team, so GameRules are AllianceBounds.
+// The minimum number of teams is the lowest the relaxation rules can bring it to.
+func (r GameRules) TeamBounds() (minTeams, maxTeams, minPlayers, maxPlayers int) {
+	minTeams, maxTeams, minPlayers, maxPlayers = r.teamBounds()
+	for _, relaxation := range r.Relaxation {
+		if relaxation.Target == RelaxMinTeamNumber {
+			minTeams = min(minTeams, int(math.Max(relaxation.lowest(float64(minTeams)), 1)))
+		}
+	}
+
+	return minTeams, maxTeams, minPlayers, maxPlayers
+}
+
+// regionLatencyCeiling returns the highest latency ceiling the relaxation rules can raise RegionLatencyMaxMs to,
+// or 0 when any latency is accepted.
+func (r GameRules) regionLatencyCeiling() int {
+	ceiling := float64(r.RegionLatencyMaxMs)
+	if ceiling == 0 {
+		return 0
+	}
+	for _, relaxation := range r.Relaxation {
+		if relaxation.Target == RelaxRegionLatencyMaxMs {
+			ceiling = math.Max(ceiling, relaxation.highest(float64(r.RegionLatencyMaxMs)))
+		}
+	}
+	if math.IsInf(ceiling, 1) {
+		return 0
+	}
+
+	return int(ceiling)
+}
+
+// teamBounds returns the number of teams and the number of players per team allowed in a match.
+// An empty alliance rule falls back to a single team of two players, and the ship counts scale the team size.
+func (r GameRules) teamBounds() (minTeams, maxTeams, minPlayers, maxPlayers int) {
+	minTeams, maxTeams = r.AllianceRule.MinNumber, r.AllianceRule.MaxNumber
+	if maxTeams == 0 {
+		maxTeams = max(minTeams, defaultTeamNumber)
+	}
+	minTeams = max(minTeams, 1)
+
+	minPlayers, maxPlayers = r.AllianceRule.PlayerMinNumber, r.AllianceRule.PlayerMaxNumber
+	if maxPlayers == 0 {
+		maxPlayers = max(minPlayers, defaultPlayerNumber)
+		if minPlayers == 0 {
+			minPlayers = defaultPlayerNumber
+		}
+	}
+	minPlayers = max(minPlayers, 1)
+
+	if r.ShipCountMin > 0 {
+		minPlayers *= r.ShipCountMin
+	}
+	if r.ShipCountMax > 0 {
+		maxPlayers *= r.ShipCountMax
+	}
+
+	return minTeams, maxTeams, minPlayers, maxPlayers
+}
+
+// statCodes returns the player attributes referenced by the rules, without duplicates
+func (r GameRules) statCodes() []string {
+	codes := []string{}
+	for _, matchingRule := range r.MatchingRule {
+		if !slices.Contains(codes, matchingRule.Attribute) {
+			codes = append(codes, matchingRule.Attribute)
+		}
+	}
+	if r.Balance.Attribute != "" && !slices.Contains(codes, r.Balance.Attribute) {
+		codes = append(codes, r.Balance.Attribute)
+	}
+
+	return codes
+}
+
+// skillAttribute returns the player attribute holding the skill, from the balance rule or else the first matching
+// rule, or an empty string when the rules do not use any
+func (r GameRules) skillAttribute() string {
+	if r.Balance.Attribute != "" {
+		return r.Balance.Attribute
+	}
+	if len(r.MatchingRule) > 0 {
+		return r.MatchingRule[0].Attribute
+	}
+
+	return ""
 }
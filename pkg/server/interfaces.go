@@ -24,6 +24,9 @@ MakeMatches returns a channel to which it will post matches as they are found, a
 all matches are exhausted.  It should also watch for cancellation on the provided scope.Ctx, at which point it should
 stop looking for matches and close the result channel.
 
+A MatchLogic that returns a nil channel from MakeMatches or BackfillMatches fails the call with an Internal error.
+Implement TypedMatchLogic instead to get the rules as a concrete type.
+
 ValidateTicket should return false AND api.ErrInvalidRequest when a ticket is not allowed to be queued
 */
 type MatchLogic interface {
@@ -36,8 +39,46 @@ type MatchLogic interface {
 	EnrichTicket(scope *common.Scope, matchTicket matchmaker.Ticket, ruleSet interface{}) (ticket matchmaker.Ticket, err error)
 }
 
-// TicketProvider provides a mechanism for a match function to get tickets from the match pool it's trying to make matches for
+/*
+TypedMatchLogic is a MatchLogic with a concrete rules type R. Wrapped with AdaptMatchLogic it serves as a MatchLogic,
+with the rules decoded once by RulesFromJSON and checked to be an R before any other method is called, so that an
+implementation never has to type assert its rules.
+*/
+type TypedMatchLogic[R any] interface {
+	BackfillMatches(scope *common.Scope, ticketProvider TicketProvider, rules R) <-chan matchmaker.BackfillProposal
+	MakeMatches(scope *common.Scope, ticketProvider TicketProvider, rules R) <-chan matchmaker.Match
+	RulesFromJSON(scope *common.Scope, json string) (R, error)
+	GetStatCodes(scope *common.Scope, rules R) []string
+	ValidateTicket(scope *common.Scope, matchTicket matchmaker.Ticket, rules R) (bool, error)
+	EnrichTicket(scope *common.Scope, matchTicket matchmaker.Ticket, rules R) (ticket matchmaker.Ticket, err error)
+}
+
+// RulesChecker is implemented by a MatchLogic that can tell whether the decoded rules are usable by it. The
+// MatchFunctionServer checks the rules before calling GetStatCodes, MakeMatches or BackfillMatches, and once more
+// after the results of MakeMatches and BackfillMatches, for rules that could only be checked once the tickets were
+// seen, and fails the call with the error.
+type RulesChecker interface {
+	CheckRules(matchRules interface{}) error
+}
+
+// checkRules checks the rules with the MatchLogic when it is a RulesChecker
+func checkRules(logic MatchLogic, matchRules interface{}) error {
+	if checker, ok := logic.(RulesChecker); ok {
+		return checker.CheckRules(matchRules)
+	}
+
+	return nil
+}
+
+// AllianceBounds is implemented by match rules that bound the number of teams of a match and the number of players of
+// a team, so that the MatchValidator can check the teams of the matches made with them
+type AllianceBounds interface {
+	TeamBounds() (minTeams, maxTeams, minPlayers, maxPlayers int)
+}
+
+// TicketProvider provides a mechanism for a match function to get tickets from the match pool it's trying to make matches for.
+// Use NewTicketSnapshot to read all the tickets at once and query them.
 type TicketProvider interface {
-	GetTickets() chan matchmaker.Ticket // I think we'd like to be able to query this, but not yet sure what that looks like
+	GetTickets() chan matchmaker.Ticket
 	GetBackfillTickets() chan matchmaker.BackfillTicket
 }
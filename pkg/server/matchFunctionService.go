@@ -7,10 +7,14 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"sync"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"matchmaking-function-grpc-plugin-server-go/pkg/common"
 	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
 	matchfunctiongrpc "matchmaking-function-grpc-plugin-server-go/pkg/pb"
@@ -20,6 +24,8 @@ import (
 type MatchFunctionServer struct {
 	matchfunctiongrpc.UnimplementedMatchFunctionServer
 	MM MatchLogic
+	// Validator checks the matches before they are sent, no check is run when nil
+	Validator *MatchValidator
 
 	shipCountMin     int
 	shipCountMax     int
@@ -61,6 +67,12 @@ func (m *MatchFunctionServer) GetStatCodes(ctx context.Context, req *matchfuncti
 		return nil, err
 	}
 
+	if err := checkRules(m.MM, rules); err != nil {
+		scope.Log.Error("rules rejected by the match logic", "error", err)
+
+		return nil, err
+	}
+
 	codes := m.MM.GetStatCodes(scope, rules)
 
 	return &matchfunctiongrpc.StatCodesResponse{Codes: codes}, nil
@@ -76,6 +88,8 @@ func (m *MatchFunctionServer) ValidateTicket(ctx context.Context, req *matchfunc
 	rules, err := m.MM.RulesFromJSON(scope, req.Rules.Json)
 	if err != nil {
 		scope.Log.Error("could not get rules from json", "error", err)
+
+		return nil, err
 	}
 
 	matchTicket := matchfunctiongrpc.ProtoTicketToMatchfunctionTicket(req.Ticket)
@@ -104,29 +118,30 @@ func (m *MatchFunctionServer) EnrichTicket(ctx context.Context, req *matchfuncti
 	return response, nil
 }
 
-// MakeMatches uses the assigned MatchMaker to build matches and sends them back to the client
+// MakeMatches uses the assigned MatchMaker to build matches and sends them back to the client.
+// The MatchLogic runs under the stream context, so a client cancellation or deadline stops it.
 func (m *MatchFunctionServer) MakeMatches(server matchfunctiongrpc.MatchFunction_MakeMatchesServer) error {
-	scope := common.ChildScopeFromRemoteScope(context.Background(), "MatchFunctionServer.MakeMatches")
-	defer scope.Finish()
-
-	matchesMade := 0
-
 	in, err := server.Recv()
 	if err != nil {
-		scope.Log.Error("error during stream Recv", "error", err)
+		slog.Default().Error("error during stream Recv", "method", "MatchFunctionServer.MakeMatches", "error", err)
 
 		return err
 	}
 
 	mrpT, ok := in.GetRequestType().(*matchfunctiongrpc.MakeMatchesRequest_Parameters)
 	if !ok {
-		scope.Log.Error("not a MakeMatchesRequest_Parameters type")
+		slog.Default().Error("not a MakeMatchesRequest_Parameters type", "method", "MatchFunctionServer.MakeMatches")
 
 		return errors.New("expected parameters in the first message were not met")
 	}
 
-	// scope := envelope.NewRootScope(context.Background(), "GRPC.MakeMatches", mrpT.Parameters.Scope.AbTraceId)
-	//defer scope.Finish()
+	ctx, cancel := context.WithCancel(server.Context())
+	defer cancel()
+
+	scope := common.NewRootScope(ctx, "MatchFunctionServer.MakeMatches", mrpT.Parameters.GetScope().GetAbTraceId())
+	defer scope.Finish()
+
+	scope.Log = scope.Log.With("tickID", mrpT.Parameters.GetTickId())
 
 	rules, err := m.MM.RulesFromJSON(scope, mrpT.Parameters.Rules.Json)
 	if err != nil {
@@ -137,93 +152,141 @@ func (m *MatchFunctionServer) MakeMatches(server matchfunctiongrpc.MatchFunction
 
 	scope.Log.Info("Retrieved rules", "rules", rules)
 
+	if err := checkRules(m.MM, rules); err != nil {
+		scope.Log.Error("rules rejected by the match logic", "error", err)
+
+		return err
+	}
+
 	ticketProvider := newMatchTicketProvider()
 	resultChan := m.MM.MakeMatches(scope, ticketProvider, rules)
+	if resultChan == nil {
+		return status.Error(codes.Internal, "match logic could not make matches with the rules")
+	}
+
 	wg := sync.WaitGroup{}
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		defer func() {
-			close(ticketProvider.channelTickets)
-			close(ticketProvider.channelBackfillTickets)
-		}()
+		m.fetchMatchTickets(scope, ticketProvider, server)
+	}()
 
-		for {
-			req, err := server.Recv()
-			if err == io.EOF {
-				scope.Log.Debug("Recv ended")
+	// the result channel is drained until the MatchLogic closes it, which it does once the tickets are exhausted
+	// or the context is cancelled, so its goroutine never blocks on a result nobody reads
+	validator := m.Validator.newStream(rules)
+	matchesMade := 0
+	var sendErr error
+	for result := range resultChan {
+		if sendErr != nil || !validator.accept(scope.Log, result) {
+			continue
+		}
 
-				return
-			}
-			if err != nil {
-				scope.Log.Debug("Recv error", "error", err)
+		scope.Log.Info("crafting a MatchResponse")
+		resp := &matchfunctiongrpc.MatchResponse{Match: matchfunctiongrpc.MatchfunctionMatchToProtoMatch(result)}
+		scope.Log.Info("match made and being sent back to the client", "response", resp)
+		if err := server.Send(resp); err != nil {
+			scope.Log.Error("error on server send", "error", err)
+			sendErr = err
+			cancel()
 
-				return
-			}
-			t, ok := req.GetRequestType().(*matchfunctiongrpc.MakeMatchesRequest_Ticket)
-			if !ok {
-				scope.Log.Error("not a MakeMatchesRequest_Ticket", "ticket", t.Ticket)
+			continue
+		}
+		matchesMade++
+	}
 
-				return
-			}
+	// the tickets are no longer read, so the receiving goroutine is stopped before the stream is left
+	cancel()
+	wg.Wait()
 
-			scope.Log.Info("crafting a matchfunctions.Ticket")
-			matchTicket := matchfunctiongrpc.ProtoTicketToMatchfunctionTicket(t.Ticket)
-			scope.Log.Info("writing match ticket", "matchTicket", matchTicket)
-			ticketProvider.channelTickets <- matchTicket
-		}
+	scope.Log.Info("make matches finished", "matchesMade", matchesMade)
+
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if err := checkRules(m.MM, rules); err != nil {
+		scope.Log.Error("rules rejected by the match logic", "error", err)
+
+		return err
+	}
+
+	return streamContextError(server.Context())
+}
+
+// fetchMatchTickets receives the match tickets of the stream and writes them to the ticket provider, until the
+// stream ends or the scope is cancelled. It closes the ticket provider channels when done.
+func (m *MatchFunctionServer) fetchMatchTickets(scope *common.Scope, ticketProvider matchTicketProvider, server matchfunctiongrpc.MatchFunction_MakeMatchesServer) {
+	defer func() {
+		close(ticketProvider.channelTickets)
+		close(ticketProvider.channelBackfillTickets)
 	}()
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for result := range resultChan {
-			scope.Log.Info("crafting a MatchResponse")
-			resp := matchfunctiongrpc.MatchResponse{Match: matchfunctiongrpc.MatchfunctionMatchToProtoMatch(result)}
-			scope.Log.Info("Response", "response", resp)
-			scope.Log.Info("match made and being sent back to the client", "response", &resp)
-			if err := server.Send(&resp); err != nil {
-				scope.Log.Error("error on server send", "error", err)
+	for {
+		req, err := server.Recv()
+		if err == io.EOF {
+			scope.Log.Debug("Recv ended")
 
-				return
-			}
-			matchesMade++
+			return
 		}
-	}()
-	wg.Wait()
+		if err != nil {
+			scope.Log.Debug("Recv error", "error", err)
 
-	scope.Log.Info("make matches finished", "matchesMade", matchesMade)
+			return
+		}
+		t, ok := req.GetRequestType().(*matchfunctiongrpc.MakeMatchesRequest_Ticket)
+		if !ok {
+			scope.Log.Error("not a MakeMatchesRequest_Ticket", "type", fmt.Sprintf("%T", req.GetRequestType()))
 
-	return nil
+			return
+		}
+
+		scope.Log.Info("crafting a matchfunctions.Ticket")
+		matchTicket := matchfunctiongrpc.ProtoTicketToMatchfunctionTicket(t.Ticket)
+		scope.Log.Info("writing match ticket", "matchTicket", matchTicket)
+		select {
+		case ticketProvider.channelTickets <- matchTicket:
+		case <-scope.Ctx.Done():
+			scope.Log.Debug("stop receiving tickets", "error", scope.Ctx.Err())
+
+			return
+		}
+	}
 }
 
-// BackfillMatches uses the assigned MatchMaker to run backfill
+// BackfillMatches uses the assigned MatchMaker to run backfill.
+// The MatchLogic runs under the stream context, so a client cancellation or deadline stops it.
 func (m *MatchFunctionServer) BackfillMatches(server matchfunctiongrpc.MatchFunction_BackfillMatchesServer) error {
-	scope := common.ChildScopeFromRemoteScope(context.Background(), "MatchFunctionServer.BackfillMatches")
-	defer scope.Finish()
-
-	scope.Log.Info("backfill matches")
+	log := slog.Default().With("method", "MatchFunctionServer.BackfillMatches")
+	log.Info("backfill matches")
 
 	in, err := server.Recv()
 	if err == io.EOF {
-		scope.Log.Debug("Recv ended")
+		log.Debug("Recv ended")
 
 		return nil
 	}
 	if err != nil {
-		scope.Log.Error("Recv error", "error", err)
+		log.Error("Recv error", "error", err)
 
 		return err
 	}
 
 	mrpT, ok := in.GetRequestType().(*matchfunctiongrpc.BackfillMakeMatchesRequest_Parameters)
 	if !ok {
-		scope.Log.Error("not a BackfillMakeMatchesRequest_Parameters type")
+		log.Error("not a BackfillMakeMatchesRequest_Parameters type")
 
 		return errors.New("expected parameters in the first message were not met")
 	}
 
+	ctx, cancel := context.WithCancel(server.Context())
+	defer cancel()
+
+	scope := common.NewRootScope(ctx, "MatchFunctionServer.BackfillMatches", mrpT.Parameters.GetScope().GetAbTraceId())
+	defer scope.Finish()
+
+	scope.Log = scope.Log.With("tickID", mrpT.Parameters.GetTickId())
+
 	rules, err := m.MM.RulesFromJSON(scope, mrpT.Parameters.Rules.Json)
 	if err != nil {
 		scope.Log.Error("could not get rules from json", "error", err)
@@ -233,36 +296,68 @@ func (m *MatchFunctionServer) BackfillMatches(server matchfunctiongrpc.MatchFunc
 
 	scope.Log.Info("Retrieved rules", "rules", rules)
 
-	ticketProvider := newMatchTicketProvider()
+	if err := checkRules(m.MM, rules); err != nil {
+		scope.Log.Error("rules rejected by the match logic", "error", err)
 
-	go m.fetchBackfillTickets(scope.Ctx, ticketProvider, server)
+		return err
+	}
 
+	ticketProvider := newMatchTicketProvider()
 	backfillProposal := m.MM.BackfillMatches(scope, ticketProvider, rules)
-	for {
-		proposal, ok := <-backfillProposal
-		if !ok {
-			scope.Log.Info("no more proposal")
+	if backfillProposal == nil {
+		return status.Error(codes.Internal, "match logic could not run backfill with the rules")
+	}
+
+	wg := sync.WaitGroup{}
 
-			return nil
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.fetchBackfillTickets(scope, ticketProvider, server)
+	}()
+
+	var sendErr error
+	for proposal := range backfillProposal {
+		if sendErr != nil {
+			continue
 		}
 
-		resp := matchfunctiongrpc.BackfillResponse{
+		resp := &matchfunctiongrpc.BackfillResponse{
 			BackfillProposal: matchfunctiongrpc.MatchfunctionBackfillProposalToProtoBackfillProposal(proposal),
 		}
 
 		scope.Log.Info("send proposal", "proposal", proposal)
 
-		err = server.Send(&resp)
-		if err != nil {
+		if err := server.Send(resp); err != nil {
 			scope.Log.Error("send proposal error", "error", err)
-
-			return err
+			sendErr = err
+			cancel()
 		}
 	}
+
+	// the tickets are no longer read, so the receiving goroutine is stopped before the stream is left
+	cancel()
+	wg.Wait()
+
+	scope.Log.Info("no more proposal")
+
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if err := checkRules(m.MM, rules); err != nil {
+		scope.Log.Error("rules rejected by the match logic", "error", err)
+
+		return err
+	}
+
+	return streamContextError(server.Context())
 }
 
-func (m *MatchFunctionServer) fetchBackfillTickets(ctx context.Context, ticketProvider matchTicketProvider, server matchfunctiongrpc.MatchFunction_BackfillMatchesServer) {
-	log := slog.Default()
+// fetchBackfillTickets receives the match tickets and backfill tickets of the stream and writes them to the ticket
+// provider, until the stream ends or the scope is cancelled. It closes the ticket provider channels when done.
+func (m *MatchFunctionServer) fetchBackfillTickets(scope *common.Scope, ticketProvider matchTicketProvider, server matchfunctiongrpc.MatchFunction_BackfillMatchesServer) {
+	log := scope.Log
 
 	defer func() {
 		close(ticketProvider.channelTickets)
@@ -287,13 +382,34 @@ func (m *MatchFunctionServer) fetchBackfillTickets(ctx context.Context, ticketPr
 			log.Info("Received match ticket",
 				"matchpool", t.MatchPool,
 				"ticketId", t.TicketID)
-			ticketProvider.channelTickets <- t
+			select {
+			case ticketProvider.channelTickets <- t:
+			case <-scope.Ctx.Done():
+				log.Debug("stop receiving tickets", "error", scope.Ctx.Err())
+
+				return
+			}
 		} else if backfillTicket := in.GetBackfillTicket(); backfillTicket != nil {
 			t := matchfunctiongrpc.ProtoBackfillTicketToMatchfunctionBackfillTicket(backfillTicket)
 			log.Info("Received backfill ticket",
 				"matchpool", t.MatchPool,
 				"ticketId", t.TicketID)
-			ticketProvider.channelBackfillTickets <- t
+			select {
+			case ticketProvider.channelBackfillTickets <- t:
+			case <-scope.Ctx.Done():
+				log.Debug("stop receiving tickets", "error", scope.Ctx.Err())
+
+				return
+			}
 		}
 	}
 }
+
+// streamContextError returns the gRPC status error of a cancelled or expired stream context, or nil
+func streamContextError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	matchfunctiongrpc "matchmaking-function-grpc-plugin-server-go/pkg/pb"
+)
+
+// fakeMakeMatchesStream hands out its requests, each after a delay, then ends
+type fakeMakeMatchesStream struct {
+	grpc.ServerStream
+	requests []*matchfunctiongrpc.MakeMatchesRequest
+}
+
+func (s *fakeMakeMatchesStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeMakeMatchesStream) Recv() (*matchfunctiongrpc.MakeMatchesRequest, error) {
+	time.Sleep(10 * time.Millisecond)
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+
+	return req, nil
+}
+
+func (s *fakeMakeMatchesStream) Send(*matchfunctiongrpc.MatchResponse) error {
+	return nil
+}
+
+// earlyMatchLogic closes its result channel right away, without reading the tickets
+type earlyMatchLogic struct {
+	MatchLogic
+	ticketProvider TicketProvider
+}
+
+func (l *earlyMatchLogic) MakeMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.Match {
+	l.ticketProvider = ticketProvider
+	results := make(chan matchmaker.Match)
+	close(results)
+
+	return results
+}
+
+func TestMakeMatchesWaitsForTicketReceiver(t *testing.T) {
+	stream := &fakeMakeMatchesStream{requests: []*matchfunctiongrpc.MakeMatchesRequest{
+		{RequestType: &matchfunctiongrpc.MakeMatchesRequest_Parameters{Parameters: &matchfunctiongrpc.MakeMatchesRequest_MakeMatchesParameters{
+			Rules: &matchfunctiongrpc.Rules{Json: `{}`},
+		}}},
+		{RequestType: &matchfunctiongrpc.MakeMatchesRequest_Ticket{Ticket: &matchfunctiongrpc.Ticket{TicketId: "a"}}},
+	}}
+	logic := &earlyMatchLogic{MatchLogic: New()}
+
+	server := &MatchFunctionServer{MM: logic}
+	if err := server.MakeMatches(stream); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the receiving goroutine closes the ticket channel when it is done
+	select {
+	case _, ok := <-logic.ticketProvider.GetTickets():
+		if ok {
+			t.Error("expected no ticket to be read")
+		}
+	default:
+		t.Error("MakeMatches returned before its ticket receiver was done")
+	}
+}
+
+func TestValidateTicketInvalidRules(t *testing.T) {
+	server := &MatchFunctionServer{MM: New()}
+	_, err := server.ValidateTicket(context.Background(), &matchfunctiongrpc.ValidateTicketRequest{
+		Ticket: &matchfunctiongrpc.Ticket{TicketId: "a", Players: []*matchfunctiongrpc.Ticket_PlayerData{{PlayerId: "a"}}},
+		Rules:  &matchfunctiongrpc.Rules{Json: `{"alliance": {"min_number": 2, "max_number": 1}}`},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected an InvalidArgument error, got %v", err)
+	}
+}
+
+// wrongRulesLogic decodes rules of another type than the MatchLogic it wraps expects
+type wrongRulesLogic struct {
+	MatchLogic
+}
+
+func (l wrongRulesLogic) RulesFromJSON(*common.Scope, string) (interface{}, error) {
+	return "not game rules", nil
+}
+
+func (l wrongRulesLogic) CheckRules(matchRules interface{}) error {
+	return checkRules(l.MatchLogic, matchRules)
+}
+
+func TestWrongRulesType(t *testing.T) {
+	logic := Chain(wrongRulesLogic{MatchLogic: New()}, LoggingMiddleware(), NewMatchLogicMetrics().Middleware())
+	server := &MatchFunctionServer{MM: logic}
+	rules := &matchfunctiongrpc.Rules{Json: `{}`}
+
+	_, err := server.GetStatCodes(context.Background(), &matchfunctiongrpc.GetStatCodesRequest{Rules: rules})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected GetStatCodes to fail with InvalidArgument, got %v", err)
+	}
+
+	err = server.MakeMatches(&fakeMakeMatchesStream{requests: []*matchfunctiongrpc.MakeMatchesRequest{
+		{RequestType: &matchfunctiongrpc.MakeMatchesRequest_Parameters{Parameters: &matchfunctiongrpc.MakeMatchesRequest_MakeMatchesParameters{Rules: rules}}},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected MakeMatches to fail with InvalidArgument, got %v", err)
+	}
+}
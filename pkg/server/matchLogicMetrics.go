@@ -0,0 +1,99 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+// MatchLogicMetrics is a prometheus.Collector of the MakeMatches and BackfillMatches calls of the MatchLogic it is
+// the Middleware of. Register it to a prometheus registry to serve the metrics.
+type MatchLogicMetrics struct {
+	started  *prometheus.CounterVec
+	failed   *prometheus.CounterVec
+	results  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewMatchLogicMetrics creates the metrics, labelled with the MatchLogic method
+func NewMatchLogicMetrics() *MatchLogicMetrics {
+	labels := []string{"method"}
+
+	return &MatchLogicMetrics{
+		started: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "match_logic_started_total",
+			Help: "Total number of MakeMatches and BackfillMatches calls started on the match logic.",
+		}, labels),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "match_logic_failed_total",
+			Help: "Total number of MakeMatches and BackfillMatches calls the match logic returned no result channel for.",
+		}, labels),
+		results: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "match_logic_results_total",
+			Help: "Total number of matches and backfill proposals made by the match logic.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "match_logic_duration_seconds",
+			Help:    "Time from a MakeMatches or BackfillMatches call until the match logic closed its result channel.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+		}, labels),
+	}
+}
+
+// Describe sends the descriptors of the metrics to the channel
+func (m *MatchLogicMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.started.Describe(ch)
+	m.failed.Describe(ch)
+	m.results.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect sends the metrics to the channel
+func (m *MatchLogicMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.started.Collect(ch)
+	m.failed.Collect(ch)
+	m.results.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// Middleware returns the Middleware recording the metrics
+func (m *MatchLogicMetrics) Middleware() Middleware {
+	return func(next MatchLogic) MatchLogic {
+		return metricsLogic{MatchLogic: next, metrics: m}
+	}
+}
+
+type metricsLogic struct {
+	MatchLogic
+	metrics *MatchLogicMetrics
+}
+
+func (l metricsLogic) MakeMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.Match {
+	return recordResults(l.metrics, "MakeMatches", l.MatchLogic.MakeMatches(scope, ticketProvider, matchRules))
+}
+
+func (l metricsLogic) BackfillMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.BackfillProposal {
+	return recordResults(l.metrics, "BackfillMatches", l.MatchLogic.BackfillMatches(scope, ticketProvider, matchRules))
+}
+
+func recordResults[T any](metrics *MatchLogicMetrics, method string, source <-chan T) <-chan T {
+	metrics.started.WithLabelValues(method).Inc()
+	if source == nil {
+		metrics.failed.WithLabelValues(method).Inc()
+
+		return nil
+	}
+
+	results := metrics.results.WithLabelValues(method)
+
+	return observeResults(source, func(T) { results.Inc() }, func(_ int, duration time.Duration) {
+		metrics.duration.WithLabelValues(method).Observe(duration.Seconds())
+	})
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+)
+
+// The checks run by the MatchValidator, used as the check label of its violations metric
+const (
+	CheckDuplicatePlayer = "duplicate_player"
+	CheckSplitTicket     = "split_ticket"
+	CheckMissingPlayer   = "missing_player"
+	CheckTeamCount       = "team_count"
+	CheckTeamSize        = "team_size"
+	CheckDuplicateTicket = "duplicate_ticket"
+)
+
+// MatchViolation is a check a match failed
+type MatchViolation struct {
+	Check  string
+	Detail string
+}
+
+func (v MatchViolation) String() string {
+	return v.Check + ": " + v.Detail
+}
+
+/*
+MatchValidator checks each match of the MatchLogic before MatchFunctionServer.MakeMatches sends it:
+  - no player is on two teams, or twice on the same team
+  - the players of a ticket are all on the same team
+  - every player of the tickets of the match is on a team
+  - the number of teams and the players of each team are within the bounds of the rules, when the rules
+    implement AllianceBounds. The minimum number of teams and team size are not checked for matches that want
+    backfill.
+  - no ticket is in two matches of the same MakeMatches call
+
+The violations are logged and counted, and in strict mode the match is dropped instead of sent.
+MatchValidator is a prometheus.Collector of its metrics.
+*/
+type MatchValidator struct {
+	// Strict drops the matches that fail a check
+	Strict bool
+
+	violations *prometheus.CounterVec
+	rejected   prometheus.Counter
+}
+
+// NewMatchValidator creates a MatchValidator
+func NewMatchValidator(strict bool) *MatchValidator {
+	return &MatchValidator{
+		Strict: strict,
+		violations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "match_validation_violations_total",
+			Help: "Total number of checks failed by the matches of the match logic.",
+		}, []string{"check"}),
+		rejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "match_validation_rejected_total",
+			Help: "Total number of matches dropped in strict mode because they failed a check.",
+		}),
+	}
+}
+
+// Describe sends the descriptors of the metrics to the channel
+func (v *MatchValidator) Describe(ch chan<- *prometheus.Desc) {
+	v.violations.Describe(ch)
+	v.rejected.Describe(ch)
+}
+
+// Collect sends the metrics to the channel
+func (v *MatchValidator) Collect(ch chan<- prometheus.Metric) {
+	v.violations.Collect(ch)
+	v.rejected.Collect(ch)
+}
+
+// newStream returns the validator of the matches of one MakeMatches call, or nil when the MatchValidator is nil
+func (v *MatchValidator) newStream(matchRules interface{}) *matchStreamValidator {
+	if v == nil {
+		return nil
+	}
+
+	return &matchStreamValidator{
+		validator:   v,
+		matchRules:  matchRules,
+		sentTickets: make(map[string]struct{}),
+	}
+}
+
+// matchStreamValidator checks the matches of one MakeMatches call, remembering the tickets already sent
+type matchStreamValidator struct {
+	validator   *MatchValidator
+	matchRules  interface{}
+	sentTickets map[string]struct{}
+}
+
+// accept checks the match, logs and counts its violations, and reports whether the match should be sent.
+// A nil matchStreamValidator accepts every match.
+func (s *matchStreamValidator) accept(log *slog.Logger, match matchmaker.Match) bool {
+	if s == nil {
+		return true
+	}
+
+	violations := s.check(match)
+	if len(violations) > 0 {
+		details := make([]string, 0, len(violations))
+		for _, violation := range violations {
+			s.validator.violations.WithLabelValues(violation.Check).Inc()
+			details = append(details, violation.String())
+		}
+
+		if s.validator.Strict {
+			s.validator.rejected.Inc()
+			log.Error("match failed validation and is dropped", "violations", details)
+
+			return false
+		}
+		log.Warn("match failed validation", "violations", details)
+	}
+
+	for _, ticket := range match.Tickets {
+		s.sentTickets[ticket.TicketID] = struct{}{}
+	}
+
+	return true
+}
+
+// check returns the violations of the match
+func (s *matchStreamValidator) check(match matchmaker.Match) []MatchViolation {
+	var violations []MatchViolation
+	violate := func(check string, format string, args ...interface{}) {
+		violations = append(violations, MatchViolation{Check: check, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	playerTeams := make(map[playerdata.ID]int)
+	for i, team := range match.Teams {
+		for _, playerID := range team.UserIDs {
+			if other, found := playerTeams[playerID]; found {
+				violate(CheckDuplicatePlayer, "player %s is on team %d and team %d", playerID, other+1, i+1)
+
+				continue
+			}
+			playerTeams[playerID] = i
+		}
+	}
+
+	matchTickets := make(map[string]struct{}, len(match.Tickets))
+	for _, ticket := range match.Tickets {
+		if _, found := matchTickets[ticket.TicketID]; found {
+			violate(CheckDuplicateTicket, "ticket %s is twice in the match", ticket.TicketID)
+		} else if _, found := s.sentTickets[ticket.TicketID]; found {
+			violate(CheckDuplicateTicket, "ticket %s was already sent in another match", ticket.TicketID)
+		}
+		matchTickets[ticket.TicketID] = struct{}{}
+
+		ticketTeam := -1
+		for _, player := range ticket.Players {
+			team, found := playerTeams[player.PlayerID]
+			if !found {
+				violate(CheckMissingPlayer, "player %s of ticket %s is on no team", player.PlayerID, ticket.TicketID)
+
+				continue
+			}
+			if ticketTeam >= 0 && team != ticketTeam {
+				violate(CheckSplitTicket, "ticket %s is split across team %d and team %d", ticket.TicketID, ticketTeam+1, team+1)
+
+				break
+			}
+			ticketTeam = team
+		}
+	}
+
+	if bounds, ok := allianceBoundsOf(s.matchRules); ok {
+		minTeams, maxTeams, minPlayers, maxPlayers := bounds.TeamBounds()
+		if len(match.Teams) > maxTeams || (len(match.Teams) < minTeams && !match.Backfill) {
+			violate(CheckTeamCount, "%d teams, the rules allow %d to %d", len(match.Teams), minTeams, maxTeams)
+		}
+		for i, team := range match.Teams {
+			players := len(team.UserIDs)
+			if players > maxPlayers || (players < minPlayers && !match.Backfill) {
+				violate(CheckTeamSize, "team %d has %d players, the rules allow %d to %d", i+1, players, minPlayers, maxPlayers)
+			}
+		}
+	}
+
+	return violations
+}
+
+// allianceBoundsOf returns the team bounds of the rules, looking through the rules of a LogicRegistry
+func allianceBoundsOf(matchRules interface{}) (AllianceBounds, bool) {
+	if rules, ok := matchRules.(*registryRules); ok {
+		matchRules = rules.resolvedRules()
+	}
+
+	bounds, ok := matchRules.(AllianceBounds)
+
+	return bounds, ok
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+)
+
+func TestMatchValidatorTeamBounds(t *testing.T) {
+	now := time.Now()
+	alliance := AllianceRule{MinNumber: 2, MaxNumber: 3, PlayerMinNumber: 1, PlayerMaxNumber: 1}
+	match := func(teams int, backfill bool) matchmaker.Match {
+		m := matchmaker.Match{Backfill: backfill}
+		for i := 0; i < teams; i++ {
+			ticket := testTicket(string(rune('a'+i)), now, 0)
+			m.Tickets = append(m.Tickets, ticket)
+			m.Teams = append(m.Teams, matchmaker.Team{UserIDs: []playerdata.ID{ticket.Players[0].PlayerID}})
+		}
+
+		return m
+	}
+
+	tests := []struct {
+		name   string
+		rules  GameRules
+		match  matchmaker.Match
+		checks []string
+	}{
+		{name: "within bounds", rules: GameRules{AllianceRule: alliance}, match: match(2, false)},
+		{name: "too many teams", rules: GameRules{AllianceRule: alliance}, match: match(4, false), checks: []string{CheckTeamCount}},
+		{name: "too few teams", rules: GameRules{AllianceRule: alliance}, match: match(1, false), checks: []string{CheckTeamCount}},
+		{name: "too few teams wanting backfill", rules: GameRules{AllianceRule: alliance}, match: match(1, true)},
+		{
+			name: "too few teams once relaxed",
+			rules: GameRules{AllianceRule: alliance, Relaxation: []RelaxationRule{
+				{Target: RelaxMinTeamNumber, Steps: []RelaxationStep{{AfterSec: 30, Value: 1}}},
+			}},
+			match: match(1, false),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checks []string
+			for _, violation := range NewMatchValidator(false).newStream(tt.rules).check(tt.match) {
+				checks = append(checks, violation.Check)
+			}
+			if !slices.Equal(checks, tt.checks) {
+				t.Errorf("expected the failed checks %v, got %v", tt.checks, checks)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"math"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+const (
+	// CriteriaDistance groups tickets whose attribute value is within a distance of the pivot ticket's value
+	CriteriaDistance = "distance"
+
+	AggregationAverage = "avg"
+	AggregationMax     = "max"
+	AggregationMin     = "min"
+)
+
+// MatchingRule constrains which tickets can be grouped according to a player attribute, such as a skill rating
+type MatchingRule struct {
+	Attribute   string  `json:"attribute"`
+	Criteria    string  `json:"criteria"`    // defaults to distance
+	Aggregation string  `json:"aggregation"` // how the players' values make up the ticket value, defaults to avg
+	Distance    float64 `json:"distance" valid:"range(0|2147483647)"`
+}
+
+// matches reports whether the ticket can be grouped with the pivot ticket
+func (r MatchingRule) matches(pivot matchmaker.Ticket, ticket matchmaker.Ticket) bool {
+	return math.Abs(ticketAttributeValue(ticket, r.Attribute, r.Aggregation)-ticketAttributeValue(pivot, r.Attribute, r.Aggregation)) <= r.Distance
+}
+
+// matchesPivot reports whether the ticket is within the distance of every matching rule from the pivot ticket
+func (r GameRules) matchesPivot(pivot matchmaker.Ticket, ticket matchmaker.Ticket) bool {
+	for _, matchingRule := range r.MatchingRule {
+		if !matchingRule.matches(pivot, ticket) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ticketAttributeValue aggregates the numeric attribute of the ticket's players into a single value.
+// A player without the attribute counts as 0.
+func ticketAttributeValue(ticket matchmaker.Ticket, attribute string, aggregation string) float64 {
+	if len(ticket.Players) == 0 {
+		return 0
+	}
+
+	var total float64
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, player := range ticket.Players {
+		value := attributeNumber(player.Attributes[attribute])
+		total += value
+		minValue = min(minValue, value)
+		maxValue = max(maxValue, value)
+	}
+
+	switch aggregation {
+	case AggregationMax:
+		return maxValue
+	case AggregationMin:
+		return minValue
+	default:
+		return total / float64(len(ticket.Players))
+	}
+}
+
+// attributeNumber returns the attribute as a float64, attributes decoded from JSON or proto structs are float64
+func attributeNumber(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		return 0
+	}
+}
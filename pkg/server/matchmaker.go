@@ -5,8 +5,8 @@
 package server
 
 import (
+	"cmp"
 	"encoding/json"
-	"fmt"
 	"slices"
 	"time"
 
@@ -15,27 +15,31 @@ import (
 
 	"matchmaking-function-grpc-plugin-server-go/pkg/common"
 	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
-	matchfunction "matchmaking-function-grpc-plugin-server-go/pkg/pb"
-	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
-
-	pie_ "github.com/elliotchance/pie/v2"
 )
 
 // New returns a MatchMaker of the MatchLogic interface
 func New() MatchLogic {
-	return MatchMaker{}
+	return AdaptMatchLogic[GameRules](MatchMaker{})
 }
 
-// ValidateTicket returns a bool if the match ticket is valid
-func (b MatchMaker) ValidateTicket(scope *common.Scope, matchTicket matchmaker.Ticket, matchRules interface{}) (bool, error) {
+// ValidateTicket returns a bool if the match ticket is valid. An invalid ticket comes with an InvalidArgument error
+// whose errdetails.ErrorInfo tells why the ticket was rejected.
+func (b MatchMaker) ValidateTicket(scope *common.Scope, matchTicket matchmaker.Ticket, rule GameRules) (bool, error) {
 	scope.Log.Info("MATCHMAKER: validate ticket")
+
+	if err := validateTicket(matchTicket, rule); err != nil {
+		scope.Log.Info("Ticket Validation failed", "ticketID", matchTicket.TicketID, "error", err)
+
+		return false, err
+	}
+
 	scope.Log.Info("Ticket Validation successful")
 
 	return true, nil
 }
 
 // EnrichTicket is responsible for adding logic to the match ticket before match making
-func (b MatchMaker) EnrichTicket(scope *common.Scope, matchTicket matchmaker.Ticket, ruleSet interface{}) (ticket matchmaker.Ticket, err error) {
+func (b MatchMaker) EnrichTicket(scope *common.Scope, matchTicket matchmaker.Ticket, ruleSet GameRules) (ticket matchmaker.Ticket, err error) {
 	scope.Log.Info("MATCHMAKER: enrich ticket")
 	if len(matchTicket.TicketAttributes) == 0 {
 		scope.Log.Info("MATCHMAKER: ticket attributes are empty, lets add some!")
@@ -50,49 +54,131 @@ func (b MatchMaker) EnrichTicket(scope *common.Scope, matchTicket matchmaker.Tic
 }
 
 // GetStatCodes returns the string slice of the stat codes in matchrules
-func (b MatchMaker) GetStatCodes(scope *common.Scope, matchRules interface{}) []string {
-	scope.Log.Info("MATCHMAKER: stat codes", "codes", []string{})
+func (b MatchMaker) GetStatCodes(scope *common.Scope, rule GameRules) []string {
+	statCodes := rule.statCodes()
+	scope.Log.Info("MATCHMAKER: stat codes", "codes", statCodes)
 
-	return []string{}
+	return statCodes
 }
 
 // RulesFromJSON returns the ruleset from the Game rules
-func (b MatchMaker) RulesFromJSON(scope *common.Scope, jsonRules string) (interface{}, error) {
+func (b MatchMaker) RulesFromJSON(scope *common.Scope, jsonRules string) (GameRules, error) {
 	var ruleSet GameRules
 	err := json.Unmarshal([]byte(jsonRules), &ruleSet)
 	if err != nil {
-		return nil, err
+		return GameRules{}, err
 	}
 
 	if ruleSet.AllianceRule.MinNumber > ruleSet.AllianceRule.MaxNumber {
-		return nil, status.Error(codes.InvalidArgument, "alliance rule MaxNumber is less than MinNumber")
+		return GameRules{}, status.Error(codes.InvalidArgument, "alliance rule MaxNumber is less than MinNumber")
 	}
 
 	if ruleSet.AllianceRule.PlayerMinNumber > ruleSet.AllianceRule.PlayerMaxNumber {
-		return nil, status.Error(codes.InvalidArgument, "alliance rule PlayerMaxNumber is less than PlayerMinNumber")
+		return GameRules{}, status.Error(codes.InvalidArgument, "alliance rule PlayerMaxNumber is less than PlayerMinNumber")
 	}
 
 	if ruleSet.ShipCountMin > ruleSet.ShipCountMax {
-		return nil, status.Error(codes.InvalidArgument, "ShipCountMax is less than ShipCountMin")
+		return GameRules{}, status.Error(codes.InvalidArgument, "ShipCountMax is less than ShipCountMin")
+	}
+
+	if ruleSet.RegionLatencyMaxMs < 0 {
+		return GameRules{}, status.Error(codes.InvalidArgument, "region_latency_max_ms is negative")
+	}
+
+	switch ruleSet.BackfillFillMode {
+	case "", BackfillFillPartial, BackfillFillComplete:
+	default:
+		return GameRules{}, status.Errorf(codes.InvalidArgument, "unknown backfill_fill_mode %q", ruleSet.BackfillFillMode)
+	}
+
+	switch ruleSet.RegionSelection {
+	case "", RegionSelectionWorstLatency, RegionSelectionAverageLatency:
+	default:
+		return GameRules{}, status.Errorf(codes.InvalidArgument, "unknown region_selection %q", ruleSet.RegionSelection)
+	}
+
+	for _, matchingRule := range ruleSet.MatchingRule {
+		if matchingRule.Attribute == "" {
+			return GameRules{}, status.Error(codes.InvalidArgument, "matching rule attribute is empty")
+		}
+
+		switch matchingRule.Criteria {
+		case "", CriteriaDistance:
+		default:
+			return GameRules{}, status.Errorf(codes.InvalidArgument, "unknown matching rule criteria %q", matchingRule.Criteria)
+		}
+
+		switch matchingRule.Aggregation {
+		case "", AggregationAverage, AggregationMax, AggregationMin:
+		default:
+			return GameRules{}, status.Errorf(codes.InvalidArgument, "unknown matching rule aggregation %q", matchingRule.Aggregation)
+		}
+
+		if matchingRule.Distance < 0 {
+			return GameRules{}, status.Error(codes.InvalidArgument, "matching rule distance is negative")
+		}
+	}
+
+	switch ruleSet.Balance.Aggregation {
+	case "", AggregationSum, AggregationAverage:
+	default:
+		return GameRules{}, status.Errorf(codes.InvalidArgument, "unknown balance aggregation %q", ruleSet.Balance.Aggregation)
+	}
+
+	switch ruleSet.Balance.Strategy {
+	case "", BalanceAuto, BalanceSnake, BalanceExhaustive, BalanceLocalSearch:
+	default:
+		return GameRules{}, status.Errorf(codes.InvalidArgument, "unknown balance strategy %q", ruleSet.Balance.Strategy)
+	}
+
+	if ruleSet.Role.enabled() {
+		if ruleSet.Role.Attribute == "" {
+			return GameRules{}, status.Error(codes.InvalidArgument, "role rule attribute is empty")
+		}
+
+		for role, count := range ruleSet.Role.Composition {
+			if count < 0 {
+				return GameRules{}, status.Errorf(codes.InvalidArgument, "role rule composition of %q is negative", role)
+			}
+		}
+
+		_, _, _, maxPlayers := ruleSet.teamBounds()
+		if len(ruleSet.Role.slots()) > maxPlayers {
+			return GameRules{}, status.Error(codes.InvalidArgument, "role rule composition has more players than a team can hold")
+		}
+	}
+
+	for _, relaxation := range ruleSet.Relaxation {
+		switch relaxation.Target {
+		case RelaxDistance:
+			if !slices.ContainsFunc(ruleSet.MatchingRule, func(m MatchingRule) bool { return m.Attribute == relaxation.Attribute }) {
+				return GameRules{}, status.Errorf(codes.InvalidArgument, "relaxation attribute %q has no matching rule", relaxation.Attribute)
+			}
+		case RelaxRegionLatencyMaxMs, RelaxMinTeamNumber:
+		default:
+			return GameRules{}, status.Errorf(codes.InvalidArgument, "unknown relaxation target %q", relaxation.Target)
+		}
+
+		switch relaxation.Schedule {
+		case "", ScheduleStep, ScheduleLinear:
+		default:
+			return GameRules{}, status.Errorf(codes.InvalidArgument, "unknown relaxation schedule %q", relaxation.Schedule)
+		}
+
+		if !slices.IsSortedFunc(relaxation.Steps, func(a, b RelaxationStep) int { return cmp.Compare(a.AfterSec, b.AfterSec) }) {
+			return GameRules{}, status.Error(codes.InvalidArgument, "relaxation steps are not in ascending after_sec order")
+		}
 	}
 
 	return ruleSet, nil
 }
 
 // MakeMatches iterates over all the match tickets and matches them based on the buildMatch function
-func (b MatchMaker) MakeMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.Match {
+func (b MatchMaker) MakeMatches(scope *common.Scope, ticketProvider TicketProvider, rule GameRules) <-chan matchmaker.Match {
 	scope.Log.Info("MATCHMAKER: make matches")
-	log := scope.Log.With("method", "MATCHMAKER.MakeMatches")
 	results := make(chan matchmaker.Match)
 	ctx := scope.Ctx
 
-	rule, ok := matchRules.(GameRules)
-	if !ok {
-		log.Error("unexpected game rule type", "type", fmt.Sprintf("%T", matchRules))
-
-		return nil
-	}
-
 	go func() {
 		defer close(results)
 		var unmatchedTickets []matchmaker.Ticket
@@ -119,207 +205,106 @@ func (b MatchMaker) MakeMatches(scope *common.Scope, ticketProvider TicketProvid
 	return results
 }
 
-// buildMatch is responsible for building matches from the slice of match tickets and feeding them to the match channel
+// buildMatch is responsible for building matches from the slice of match tickets and feeding them to the match channel.
+// No match could be made from the unmatched tickets when the last of them arrived, so only a match with the new
+// ticket is looked for, and at most one match is made per ticket. The unmatched tickets are kept oldest first.
+// When the context is done before the match is sent, the match is dropped and its tickets remain unmatched.
 func buildMatch(scope *common.Scope, ticket matchmaker.Ticket, unmatchedTickets []matchmaker.Ticket, rule GameRules, results chan matchmaker.Match) []matchmaker.Ticket {
 	scope.Log.Info("MATCHMAKER: seeing if we have enough tickets to match")
-	unmatchedTickets = append(unmatchedTickets, ticket)
+	now := time.Now()
+	unmatchedTickets, required := insertByAge(unmatchedTickets, ticket, now)
 
-	minPlayers := rule.AllianceRule.MinNumber * rule.AllianceRule.PlayerMinNumber
-	maxPlayers := rule.AllianceRule.MaxNumber * rule.AllianceRule.PlayerMaxNumber
+	used, teams, relaxed := findTeams(unmatchedTickets, required, rule, now)
+	if teams == nil {
+		scope.Log.Info("MATCHMAKER: not enough tickets to build a match")
 
-	if minPlayers == 0 && maxPlayers == 0 {
-		minPlayers = 2
-		maxPlayers = 2
+		return unmatchedTickets
 	}
 
-	if rule.ShipCountMin == 0 {
-		minPlayers *= 1
-	} else {
-		minPlayers *= rule.ShipCountMin
-	}
+	scope.Log.Info("MATCHMAKER: I have enough tickets to match!", "teams", len(teams))
 
-	if rule.ShipCountMax == 0 {
-		maxPlayers *= 1
-	} else {
-		maxPlayers *= rule.ShipCountMax
+	backfill := false
+	if rule.AutoBackfill && !teams.isFull(rule) {
+		backfill = true
 	}
 
-	for {
-		if len(unmatchedTickets) < minPlayers {
-			break
-		}
-
-		numPlayers := minPlayers
-		if len(unmatchedTickets) >= maxPlayers {
-			numPlayers = maxPlayers
-		}
-
-		scope.Log.Info("MATCHMAKER: I have enough tickets to match!")
+	match := teams.toMatch(relaxed, backfill)
+	scope.Log.Info("MATCHMAKER: sending to results channel")
+	select {
+	case results <- match:
+	case <-scope.Ctx.Done():
+		scope.Log.Info("MATCHMAKER: CTX Done triggered")
 
-		backfill := false
-		if rule.AutoBackfill && numPlayers < maxPlayers {
-			backfill = true
-		}
-
-		var players []playerdata.PlayerData
-
-		for i := 0; i < numPlayers; i++ {
-			players = append(players, unmatchedTickets[i].Players...)
-		}
-		playerIDs := pie_.Map(players, playerdata.ToID)
-
-		// RegionPreference value is just an example. The value(s) should be from the best region on the matchmaker.Ticket.Latencies
-		teamID := common.GenerateUUID()
-		match := matchmaker.Match{
-			RegionPreference: []string{"us-east-2", "us-west-2"},
-			Tickets:          make([]matchmaker.Ticket, numPlayers),
-			Teams: []matchmaker.Team{
-				{UserIDs: playerIDs, TeamID: teamID},
-			},
-			Backfill: backfill,
-			MatchAttributes: map[string]interface{}{
-				"assignment": map[string]interface{}{
-					"small-team-1": []string{teamID},
-				},
-			},
-		}
-		copy(match.Tickets, unmatchedTickets)
-		scope.Log.Info("MATCHMAKER: sending to results channel")
-		results <- match
-		scope.Log.Info("MATCHMAKER: reducing unmatched tickets",
-			"from", len(unmatchedTickets),
-			"to", len(unmatchedTickets)-numPlayers)
-		unmatchedTickets = unmatchedTickets[numPlayers:]
+		return unmatchedTickets
 	}
+	scope.Log.Info("MATCHMAKER: reducing unmatched tickets",
+		"from", len(unmatchedTickets),
+		"to", len(unmatchedTickets)-len(used))
 
-	scope.Log.Info("MATCHMAKER: not enough tickets to build a match")
-
-	return unmatchedTickets
+	return removeTickets(unmatchedTickets, used)
 }
 
-func (b MatchMaker) BackfillMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.BackfillProposal {
+// BackfillMatches takes a TicketSnapshot of the match tickets and the backfill tickets, then proposes tickets to the
+// backfill sessions
+func (b MatchMaker) BackfillMatches(scope *common.Scope, ticketProvider TicketProvider, rule GameRules) <-chan matchmaker.BackfillProposal {
 	results := make(chan matchmaker.BackfillProposal)
 	ctx := scope.Ctx
 
 	scope.Log.With("method", "MatchMaker.BackfillMatches")
 	scope.Log.Info("start")
 
-	rule, ok := matchRules.(GameRules)
-	if !ok {
-		scope.Log.Error("unexpected game rule type", "type", fmt.Sprintf("%T", matchRules))
-
-		return nil
-	}
-
 	go func() {
 		defer func() {
 			close(results)
 			scope.Log.Info("end backfill")
 		}()
-		var unmatchedTickets []matchmaker.Ticket
-		var unmatchedBackfillTickets []matchmaker.BackfillTicket
-		nextTicket := ticketProvider.GetTickets()
-		nextBackfillTicket := ticketProvider.GetBackfillTickets()
 
-		for {
-			if nextTicket == nil && nextBackfillTicket == nil {
-				return
-			}
+		snapshot, err := NewTicketSnapshot(ctx, ticketProvider)
+		if err != nil {
+			scope.Log.Info("CTX Done triggered")
 
-			select {
-			case ticket, ok := <-nextTicket:
-				if !ok {
-					scope.Log.Info("no more match tickets")
-					nextTicket = nil
-
-					continue
-				}
-				scope.Log.Info("got a match ticket", "ticketId", ticket.TicketID)
-				unmatchedTickets, unmatchedBackfillTickets = buildBackfillMatch(scope, &ticket, nil, unmatchedTickets, unmatchedBackfillTickets, rule, results)
-			case backfillTicket, ok := <-nextBackfillTicket:
-				if !ok {
-					scope.Log.Info("no more backfill tickets")
-					nextBackfillTicket = nil
-
-					continue
-				}
-				scope.Log.Info("got a backfill ticket", "ticketId", backfillTicket.TicketID)
-				unmatchedTickets, unmatchedBackfillTickets = buildBackfillMatch(scope, nil, &backfillTicket, unmatchedTickets, unmatchedBackfillTickets, rule, results)
-			case <-ctx.Done():
-				scope.Log.Info("CTX Done triggered")
-
-				return
-			}
+			return
 		}
+
+		tickets, backfillTickets := snapshot.Tickets(), snapshot.BackfillTickets()
+		scope.Log.Info("got the tickets", "matchTickets", len(tickets), "backfillTickets", len(backfillTickets))
+		buildBackfillMatch(scope, tickets, backfillTickets, rule, results)
 	}()
 
 	return results
 }
 
-// buildBackfillMatch is responsible for building matches from the slice of match tickets and feeding them to the match channel
-func buildBackfillMatch(scope *common.Scope, newTicket *matchmaker.Ticket, newBackfillTicket *matchmaker.BackfillTicket, unmatchedTickets []matchmaker.Ticket, unmatchedBackfillTickets []matchmaker.BackfillTicket, rule GameRules, results chan matchmaker.BackfillProposal) ([]matchmaker.Ticket, []matchmaker.BackfillTicket) {
+// buildBackfillMatch proposes to each backfill ticket, in order, the match tickets picked by proposeBackfill and feeds
+// the proposals to the results channel. The match tickets of a proposal are only taken out of the candidates, and the
+// backfill ticket is only retired, once the proposal has been sent. Candidates left out of a proposal remain
+// available to the next backfill tickets.
+// It returns the match tickets and the backfill tickets left without a proposal.
+func buildBackfillMatch(scope *common.Scope, unmatchedTickets []matchmaker.Ticket, unmatchedBackfillTickets []matchmaker.BackfillTicket, rule GameRules, results chan matchmaker.BackfillProposal) ([]matchmaker.Ticket, []matchmaker.BackfillTicket) {
 	log := scope.Log.With("method", "MATCHMAKER.buildBackfillMatch")
 
-	if newTicket != nil {
-		unmatchedTickets = append(unmatchedTickets, *newTicket)
-	}
-	if newBackfillTicket != nil {
-		unmatchedBackfillTickets = append(unmatchedBackfillTickets, *newBackfillTicket)
-	}
-
 	log.Info("buildBackfillMatch",
 		"numBackfill", len(unmatchedBackfillTickets),
 		"numTicket", len(unmatchedTickets))
 
-	if len(unmatchedBackfillTickets) > 0 && len(unmatchedTickets) > 0 {
-		log.Info("I have enough tickets to backfill!")
-		var i int
-		var backfillTicket matchmaker.BackfillTicket
-		for i, backfillTicket = range unmatchedBackfillTickets {
-			ticket := unmatchedTickets[0]
-			unmatchedTickets = unmatchedTickets[1:]
-
-			if ticket.ExcludedSessions != nil && slices.Contains(ticket.ExcludedSessions, backfillTicket.MatchSessionID) {
-				log.Info("skip backfilling ticket to previous session",
-					"ticketID", ticket.TicketID,
-					"sessionID", backfillTicket.MatchSessionID)
-				continue
-			}
+	var remainingBackfillTickets []matchmaker.BackfillTicket
+	for i, backfillTicket := range unmatchedBackfillTickets {
+		proposal, used, ok := proposeBackfill(log, backfillTicket, unmatchedTickets, rule)
+		if !ok {
+			remainingBackfillTickets = append(remainingBackfillTickets, backfillTicket)
 
-			proposedTeam := backfillTicket.PartialMatch.Teams
-			proposedTeam = append(proposedTeam, matchmaker.Team{
-				UserIDs: pie_.Map(ticket.Players, playerdata.ToID),
-				Parties: matchfunction.PlayerDataToParties(ticket.Players),
-				TeamID:  common.GenerateUUID(),
-			})
-
-			log.Info("Send backfill proposal!")
-			proposedAttributes := backfillTicket.PartialMatch.MatchAttributes
-			proposedAttributes["generatedID"] = common.GenerateUUID()
-			results <- matchmaker.BackfillProposal{
-				BackfillTicketID: backfillTicket.TicketID,
-				CreatedAt:        time.Time{},
-				AddedTickets:     []matchmaker.Ticket{ticket},
-				ProposedTeams:    proposedTeam,
-				ProposalID:       common.GenerateUUID(),
-				MatchPool:        backfillTicket.MatchPool,
-				MatchSessionID:   backfillTicket.MatchSessionID,
-				Attributes:       proposedAttributes,
-			}
+			continue
+		}
 
-			if len(unmatchedTickets) == 0 {
-				if i+1 < len(unmatchedBackfillTickets) {
-					unmatchedBackfillTickets = unmatchedBackfillTickets[i+1:]
-				} else {
-					unmatchedBackfillTickets = nil
-				}
+		log.Info("Send backfill proposal!", "tickets", len(used))
+		select {
+		case results <- proposal:
+		case <-scope.Ctx.Done():
+			log.Info("CTX Done triggered")
 
-				break
-			}
+			return unmatchedTickets, append(remainingBackfillTickets, unmatchedBackfillTickets[i:]...)
 		}
-	} else {
-		log.Info("not enough tickets to build a match")
+		unmatchedTickets = removeTickets(unmatchedTickets, used)
 	}
 
-	return unmatchedTickets, unmatchedBackfillTickets
+	return unmatchedTickets, remainingBackfillTickets
 }
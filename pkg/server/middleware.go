@@ -0,0 +1,112 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+/*
+Middleware wraps a MatchLogic into another MatchLogic, the way a gRPC interceptor wraps a handler, to add behavior
+such as logging, metrics or ticket sanitization in front of any MatchLogic without changing it.
+
+A Middleware usually returns a struct embedding the MatchLogic it wraps and overrides only the methods it needs.
+Results of MakeMatches and BackfillMatches should be passed on until the wrapped channel is closed, and a nil
+channel returned as nil. Chain passes CheckRules on to the wrapped MatchLogic when the Middleware does not, since a
+RulesChecker is not visible through the embedded MatchLogic.
+*/
+type Middleware func(next MatchLogic) MatchLogic
+
+// Chain wraps the MatchLogic with the middlewares. The first middleware is the outermost one, so it is the first to
+// see a call. The rules checks of a wrapped MatchLogic are passed on through a middleware that has none of its own.
+func Chain(logic MatchLogic, middlewares ...Middleware) MatchLogic {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		next := logic
+		logic = middlewares[i](next)
+
+		if checker, ok := next.(RulesChecker); ok {
+			if _, checks := logic.(RulesChecker); !checks {
+				logic = checkingLogic{MatchLogic: logic, checker: checker}
+			}
+		}
+	}
+
+	return logic
+}
+
+// checkingLogic passes CheckRules on to the MatchLogic a middleware wrapped
+type checkingLogic struct {
+	MatchLogic
+	checker RulesChecker
+}
+
+func (l checkingLogic) CheckRules(matchRules interface{}) error {
+	return l.checker.CheckRules(matchRules)
+}
+
+// LoggingMiddleware logs when MakeMatches and BackfillMatches start, and how many results they produced and how
+// long they took once their result channel is closed
+func LoggingMiddleware() Middleware {
+	return func(next MatchLogic) MatchLogic {
+		return loggingLogic{MatchLogic: next}
+	}
+}
+
+type loggingLogic struct {
+	MatchLogic
+}
+
+func (l loggingLogic) MakeMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.Match {
+	return logResults(scope, "MakeMatches", l.MatchLogic.MakeMatches(scope, ticketProvider, matchRules))
+}
+
+func (l loggingLogic) BackfillMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.BackfillProposal {
+	return logResults(scope, "BackfillMatches", l.MatchLogic.BackfillMatches(scope, ticketProvider, matchRules))
+}
+
+func logResults[T any](scope *common.Scope, method string, source <-chan T) <-chan T {
+	log := scope.Log.With("matchLogicMethod", method)
+	if source == nil {
+		log.Error("match logic returned no result channel")
+
+		return nil
+	}
+
+	log.Info("match logic started")
+
+	return observeResults(source, nil, func(results int, duration time.Duration) {
+		log.Info("match logic finished", "results", results, "duration", duration.String())
+	})
+}
+
+// observeResults returns a channel passing on the results of source. onResult, when set, is called before each
+// result is passed on, and onClose once source is closed, with the number of results and the time since the call.
+func observeResults[T any](source <-chan T, onResult func(T), onClose func(results int, duration time.Duration)) <-chan T {
+	if source == nil {
+		return nil
+	}
+
+	start := time.Now()
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		results := 0
+		for result := range source {
+			results++
+			if onResult != nil {
+				onResult(result)
+			}
+			out <- result
+		}
+		onClose(results, time.Since(start))
+	}()
+
+	return out
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"cmp"
+	"slices"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+const (
+	// RegionSelectionWorstLatency orders the regions by the highest latency of any ticket in the match
+	RegionSelectionWorstLatency = "worst_latency"
+	// RegionSelectionAverageLatency orders the regions by the average latency of the tickets in the match
+	RegionSelectionAverageLatency = "average_latency"
+)
+
+// acceptableRegions returns the set of regions the ticket can play in, that is the regions with a latency within
+// maxLatencyMs, or every region with a latency when maxLatencyMs is 0.
+// It returns nil when the ticket has no latency and can therefore play in any region.
+func acceptableRegions(ticket matchmaker.Ticket, maxLatencyMs int) map[string]struct{} {
+	if len(ticket.Latencies) == 0 {
+		return nil
+	}
+
+	regions := make(map[string]struct{}, len(ticket.Latencies))
+	for region, latency := range ticket.Latencies {
+		if maxLatencyMs == 0 || latency <= int64(maxLatencyMs) {
+			regions[region] = struct{}{}
+		}
+	}
+
+	return regions
+}
+
+// intersectRegions returns the regions found in both sets, where a nil set stands for any region
+func intersectRegions(a, b map[string]struct{}) map[string]struct{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	regions := make(map[string]struct{})
+	for region := range a {
+		if _, ok := b[region]; ok {
+			regions[region] = struct{}{}
+		}
+	}
+
+	return regions
+}
+
+// regionPreference returns the regions acceptable to every ticket, ordered from best to worst according to the
+// region selection of the rule. It returns nil when none of the tickets has a latency.
+func regionPreference(tickets []matchmaker.Ticket, rule GameRules) []string {
+	var regions map[string]struct{}
+	for _, ticket := range tickets {
+		regions = intersectRegions(regions, acceptableRegions(ticket, rule.RegionLatencyMaxMs))
+	}
+	if regions == nil {
+		return nil
+	}
+
+	scores := make(map[string]float64, len(regions))
+	for region := range regions {
+		scores[region] = regionScore(tickets, region, rule.RegionSelection)
+	}
+
+	preference := make([]string, 0, len(regions))
+	for region := range regions {
+		preference = append(preference, region)
+	}
+	slices.SortFunc(preference, func(a, b string) int {
+		if c := cmp.Compare(scores[a], scores[b]); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a, b)
+	})
+
+	return preference
+}
+
+// regionScore returns the latency score of the region over the tickets that report a latency, lower is better
+func regionScore(tickets []matchmaker.Ticket, region string, selection string) float64 {
+	var worst, total int64
+	var count int
+	for _, ticket := range tickets {
+		latency, ok := ticket.Latencies[region]
+		if !ok {
+			continue
+		}
+		worst = max(worst, latency)
+		total += latency
+		count++
+	}
+
+	if selection == RegionSelectionAverageLatency && count > 0 {
+		return float64(total) / float64(count)
+	}
+
+	return float64(worst)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+// latencyTicket returns a solo ticket with the given latency per region
+func latencyTicket(id string, latencies map[string]int64) matchmaker.Ticket {
+	ticket := testTicket(id, time.Now(), 0)
+	ticket.Latencies = latencies
+
+	return ticket
+}
+
+func TestRegionPreference(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       GameRules
+		latencies  []map[string]int64
+		preference []string
+	}{
+		{
+			name:       "no latencies",
+			latencies:  []map[string]int64{nil, nil},
+			preference: nil,
+		},
+		{
+			name:       "lowest worst latency first",
+			latencies:  []map[string]int64{{"us": 10, "eu": 60}, {"us": 90, "eu": 50}},
+			preference: []string{"eu", "us"},
+		},
+		{
+			name:       "lowest average latency first",
+			rule:       GameRules{RegionSelection: RegionSelectionAverageLatency},
+			latencies:  []map[string]int64{{"us": 10, "eu": 60}, {"us": 90, "eu": 50}},
+			preference: []string{"us", "eu"},
+		},
+		{
+			name:       "equal latencies by name",
+			latencies:  []map[string]int64{{"us": 50, "eu": 50}},
+			preference: []string{"eu", "us"},
+		},
+		{
+			name:       "regions over the ceiling left out",
+			rule:       GameRules{RegionLatencyMaxMs: 80},
+			latencies:  []map[string]int64{{"us": 10, "eu": 60}, {"us": 90, "eu": 50}},
+			preference: []string{"eu"},
+		},
+		{
+			name:       "only regions shared by every ticket",
+			latencies:  []map[string]int64{{"us": 10, "eu": 60}, {"eu": 50, "ap": 20}},
+			preference: []string{"eu"},
+		},
+		{
+			name:       "ticket without latencies plays anywhere",
+			latencies:  []map[string]int64{{"us": 10, "eu": 60}, nil},
+			preference: []string{"us", "eu"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tickets []matchmaker.Ticket
+			for i, latencies := range tt.latencies {
+				tickets = append(tickets, latencyTicket(fmt.Sprint(i), latencies))
+			}
+
+			preference := regionPreference(tickets, tt.rule)
+			if fmt.Sprint(preference) != fmt.Sprint(tt.preference) {
+				t.Errorf("expected region preference %v, got %v", tt.preference, preference)
+			}
+		})
+	}
+}
+
+func TestBuildMatchRegions(t *testing.T) {
+	tests := []struct {
+		name       string
+		latencies  []map[string]int64
+		preference []string // nil when no match is made
+	}{
+		{
+			name:       "shared region",
+			latencies:  []map[string]int64{{"us": 10, "eu": 200}, {"us": 50}},
+			preference: []string{"us"},
+		},
+		{
+			name:      "no shared region within the ceiling",
+			latencies: []map[string]int64{{"us": 10, "eu": 200}, {"eu": 50}},
+		},
+		{
+			name:      "no shared region",
+			latencies: []map[string]int64{{"us": 10}, {"eu": 10}},
+		},
+	}
+
+	rule := GameRules{
+		AllianceRule:       AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 1, PlayerMaxNumber: 1},
+		RegionLatencyMaxMs: 100,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(chan matchmaker.Match, 1)
+			var unmatched []matchmaker.Ticket
+			for i, latencies := range tt.latencies {
+				unmatched = buildMatch(testScope(), latencyTicket(fmt.Sprint(i), latencies), unmatched, rule, results)
+			}
+			close(results)
+
+			match, ok := <-results
+			if ok != (tt.preference != nil) {
+				t.Fatalf("expected a match %v, got %v", tt.preference != nil, ok)
+			}
+			if ok && fmt.Sprint(match.RegionPreference) != fmt.Sprint(tt.preference) {
+				t.Errorf("expected region preference %v, got %v", tt.preference, match.RegionPreference)
+			}
+			if !ok && len(unmatched) != len(tt.latencies) {
+				t.Errorf("expected %d unmatched tickets, got %d", len(tt.latencies), len(unmatched))
+			}
+		})
+	}
+}
+
+func TestRulesFromJSONRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "no region rule", rules: `{}`},
+		{name: "latency ceiling", rules: `{"region_latency_max_ms": 100}`},
+		{name: "negative latency ceiling", rules: `{"region_latency_max_ms": -1}`, wantErr: true},
+		{name: "worst latency selection", rules: `{"region_selection": "worst_latency"}`},
+		{name: "average latency selection", rules: `{"region_selection": "average_latency"}`},
+		{name: "unknown selection", rules: `{"region_selection": "closest"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MatchMaker{}.RulesFromJSON(testScope(), tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && status.Code(err) != codes.InvalidArgument {
+				t.Errorf("expected an InvalidArgument error, got %v", err)
+			}
+		})
+	}
+}
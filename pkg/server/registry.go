@@ -0,0 +1,435 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+// DefaultLogicName is the name the default MatchLogic of a LogicRegistry is registered under
+const DefaultLogicName = "default"
+
+/*
+LogicRegistry is a MatchLogic that hands every call to one of the MatchLogic implementations registered in it, so
+that one deployed function can serve match pools with different logic. The MatchLogic is picked, in order, by
+the `logic` field of the rules JSON, by the match pool of the tickets, or else is the default one.
+
+Rules without a `logic` field are decoded and validated by the default MatchLogic right away, so that invalid rules
+fail RulesFromJSON. The match pool is only known once a ticket is seen, so such rules are decoded again by the
+MatchLogic of the match pool, when it is not the default one, once the first ticket of a call arrives. An error
+then fails the call, as reported by CheckRules once its results are over. GetStatCodes has no ticket, so it is
+answered by the MatchLogic named in the rules, or by the default one.
+*/
+type LogicRegistry struct {
+	logics map[string]MatchLogic
+	pools  map[string]string
+}
+
+// NewLogicRegistry creates a LogicRegistry that falls back to defaultLogic
+func NewLogicRegistry(defaultLogic MatchLogic) *LogicRegistry {
+	return &LogicRegistry{
+		logics: map[string]MatchLogic{DefaultLogicName: defaultLogic},
+		pools:  make(map[string]string),
+	}
+}
+
+// Register adds a MatchLogic that rules pick with `"logic": "<name>"`, or that RegisterPool assigns to pools
+func (r *LogicRegistry) Register(name string, logic MatchLogic) {
+	r.logics[name] = logic
+}
+
+// RegisterPool makes the tickets of the match pool use the MatchLogic registered under name
+func (r *LogicRegistry) RegisterPool(pool string, name string) error {
+	if _, ok := r.logics[name]; !ok {
+		return fmt.Errorf("no match logic registered as %q for match pool %q", name, pool)
+	}
+	r.pools[pool] = name
+
+	return nil
+}
+
+// registryRules are the rules returned by LogicRegistry.RulesFromJSON. The rules are decoded right away by the
+// MatchLogic they name, or else by the default MatchLogic and once more by the MatchLogic of the match pool once it
+// is known.
+type registryRules struct {
+	json    string
+	name    string     // the name of the MatchLogic named by the rules, empty when it is picked by match pool
+	logic   MatchLogic // the MatchLogic named by the rules, nil when it is picked by match pool
+	decoded interface{}
+
+	lock sync.Mutex
+	// pool is the match pool the rules were last decoded for
+	pool string
+	// resolved are the rules last decoded once the match pool was known
+	resolved interface{}
+	// err is the first error of decoding or using the rules once the match pool was known
+	err error
+}
+
+// resolvedRules returns the decoded rules, or nil when they have not been decoded yet
+func (r *registryRules) resolvedRules() interface{} {
+	if r.logic != nil {
+		return r.decoded
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.resolved
+}
+
+// LogValue logs the name of the MatchLogic or the match pool the rules are for, and the decoded rules, leaving out
+// the rules JSON and the MatchLogic itself
+func (r *registryRules) LogValue() slog.Value {
+	if r.logic != nil {
+		return slog.GroupValue(slog.String("logic", r.name), slog.Any("rules", r.decoded))
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.resolved == nil {
+		return slog.GroupValue(slog.String("logic", DefaultLogicName), slog.Any("rules", r.decoded))
+	}
+
+	return slog.GroupValue(slog.String("matchPool", r.pool), slog.Any("rules", r.resolved))
+}
+
+// fail records the error of the rules, keeping the first one
+func (r *registryRules) fail(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+type logicSelector struct {
+	Logic string `json:"logic"`
+}
+
+// named returns the MatchLogic named by the `logic` field of the rules along with its name, which is empty when the
+// rules name none
+func (r *LogicRegistry) named(jsonRules string) (MatchLogic, string, error) {
+	var selector logicSelector
+	if err := json.Unmarshal([]byte(jsonRules), &selector); err != nil {
+		return nil, "", status.Errorf(codes.InvalidArgument, "invalid rules json: %v", err)
+	}
+
+	if selector.Logic == "" {
+		return nil, "", nil
+	}
+
+	logic, ok := r.logics[selector.Logic]
+	if !ok {
+		return nil, "", status.Errorf(codes.InvalidArgument, "unknown match logic %q", selector.Logic)
+	}
+
+	return logic, selector.Logic, nil
+}
+
+// forPool returns the name of the MatchLogic of the match pool
+func (r *LogicRegistry) forPool(pool string) string {
+	if name, ok := r.pools[pool]; ok {
+		return name
+	}
+
+	return DefaultLogicName
+}
+
+func registryRulesOf(matchRules interface{}) (*registryRules, error) {
+	rules, ok := matchRules.(*registryRules)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected rules type %T", matchRules)
+	}
+
+	return rules, nil
+}
+
+// resolve returns the MatchLogic for the match pool along with the rules decoded by it. An error is also recorded
+// in the rules, for CheckRules to report.
+func (r *LogicRegistry) resolve(scope *common.Scope, matchRules interface{}, pool string) (MatchLogic, interface{}, error) {
+	rules, err := registryRulesOf(matchRules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rules.logic != nil {
+		return rules.logic, rules.decoded, nil
+	}
+
+	name := r.forPool(pool)
+	logic, decoded := r.logics[name], rules.decoded
+	if name != DefaultLogicName {
+		decoded, err = logic.RulesFromJSON(scope, rules.json)
+		if err == nil {
+			err = checkRules(logic, decoded)
+		}
+		if err != nil {
+			rules.fail(err)
+
+			return nil, nil, err
+		}
+	}
+
+	rules.lock.Lock()
+	rules.resolved, rules.pool = decoded, pool
+	rules.lock.Unlock()
+
+	return logic, decoded, nil
+}
+
+// RulesFromJSON decodes the rules with the MatchLogic named by their `logic` field, or else with the default
+// MatchLogic. Rules without a `logic` field are decoded again once the match pool is known.
+func (r *LogicRegistry) RulesFromJSON(scope *common.Scope, jsonRules string) (interface{}, error) {
+	logic, name, err := r.named(jsonRules)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		logic = r.logics[DefaultLogicName]
+	}
+
+	decoded, err := logic.RulesFromJSON(scope, jsonRules)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &registryRules{json: jsonRules, name: name, decoded: decoded}
+	if name != "" {
+		rules.logic = logic
+	}
+
+	return rules, nil
+}
+
+// CheckRules checks the rules with the MatchLogic that decoded them, and returns the error that failed a call made
+// with them once the match pool was known
+func (r *LogicRegistry) CheckRules(matchRules interface{}) error {
+	rules, err := registryRulesOf(matchRules)
+	if err != nil {
+		return err
+	}
+
+	rules.lock.Lock()
+	err = rules.err
+	rules.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	logic := rules.logic
+	if logic == nil {
+		logic = r.logics[DefaultLogicName]
+	}
+
+	return checkRules(logic, rules.decoded)
+}
+
+// GetStatCodes returns the stat codes of the MatchLogic named in the rules, or of the default MatchLogic
+func (r *LogicRegistry) GetStatCodes(scope *common.Scope, matchRules interface{}) []string {
+	logic, rules, err := r.resolve(scope, matchRules, "")
+	if err != nil {
+		scope.Log.Error("could not resolve match logic", "error", err)
+
+		return nil
+	}
+
+	return logic.GetStatCodes(scope, rules)
+}
+
+// ValidateTicket validates the ticket with the MatchLogic of its match pool
+func (r *LogicRegistry) ValidateTicket(scope *common.Scope, matchTicket matchmaker.Ticket, matchRules interface{}) (bool, error) {
+	logic, rules, err := r.resolve(scope, matchRules, matchTicket.MatchPool)
+	if err != nil {
+		return false, err
+	}
+
+	return logic.ValidateTicket(scope, matchTicket, rules)
+}
+
+// EnrichTicket enriches the ticket with the MatchLogic of its match pool. The rule set is passed on as it is.
+func (r *LogicRegistry) EnrichTicket(scope *common.Scope, matchTicket matchmaker.Ticket, ruleSet interface{}) (matchmaker.Ticket, error) {
+	logic := r.logics[r.forPool(matchTicket.MatchPool)]
+	if rules, ok := ruleSet.(interface{ GetJson() string }); ok {
+		if jsonRules := rules.GetJson(); jsonRules != "" {
+			named, name, err := r.named(jsonRules)
+			if err != nil {
+				return matchTicket, err
+			}
+			if name != "" {
+				logic = named
+			}
+		}
+	}
+
+	return logic.EnrichTicket(scope, matchTicket, ruleSet)
+}
+
+// MakeMatches makes the matches with the MatchLogic of the match pool of the first ticket
+func (r *LogicRegistry) MakeMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.Match {
+	results := make(chan matchmaker.Match)
+
+	go func() {
+		defer close(results)
+
+		var first []matchmaker.Ticket
+		tickets := ticketProvider.GetTickets()
+		select {
+		case ticket, ok := <-tickets:
+			if !ok {
+				return
+			}
+			first = append(first, ticket)
+		case <-scope.Ctx.Done():
+			return
+		}
+
+		logic, rules, err := r.resolve(scope, matchRules, first[0].MatchPool)
+		if err != nil {
+			scope.Log.Error("could not resolve match logic", "matchPool", first[0].MatchPool, "error", err)
+
+			return
+		}
+
+		provider := registryTicketProvider{
+			tickets:         forwardTickets(scope.Ctx, first, tickets),
+			backfillTickets: forwardTickets(scope.Ctx, nil, ticketProvider.GetBackfillTickets()),
+		}
+		forwardResults(scope.Ctx, matchRules, logic.MakeMatches(scope, provider, rules), results)
+	}()
+
+	return results
+}
+
+// BackfillMatches runs backfill with the MatchLogic of the match pool of the first ticket or backfill ticket
+func (r *LogicRegistry) BackfillMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.BackfillProposal {
+	results := make(chan matchmaker.BackfillProposal)
+
+	go func() {
+		defer close(results)
+
+		var firstTickets []matchmaker.Ticket
+		var firstBackfillTickets []matchmaker.BackfillTicket
+		var pool string
+		tickets, backfillTickets := ticketProvider.GetTickets(), ticketProvider.GetBackfillTickets()
+		for len(firstTickets) == 0 && len(firstBackfillTickets) == 0 {
+			// a closed channel is set to nil so that it is no longer selected, and is forwarded as closed
+			select {
+			case ticket, ok := <-tickets:
+				if !ok {
+					tickets = nil
+				} else {
+					firstTickets = append(firstTickets, ticket)
+					pool = ticket.MatchPool
+				}
+			case backfillTicket, ok := <-backfillTickets:
+				if !ok {
+					backfillTickets = nil
+				} else {
+					firstBackfillTickets = append(firstBackfillTickets, backfillTicket)
+					pool = backfillTicket.MatchPool
+				}
+			case <-scope.Ctx.Done():
+				return
+			}
+
+			if tickets == nil && backfillTickets == nil {
+				return
+			}
+		}
+
+		logic, rules, err := r.resolve(scope, matchRules, pool)
+		if err != nil {
+			scope.Log.Error("could not resolve match logic", "matchPool", pool, "error", err)
+
+			return
+		}
+
+		provider := registryTicketProvider{
+			tickets:         forwardTickets(scope.Ctx, firstTickets, tickets),
+			backfillTickets: forwardTickets(scope.Ctx, firstBackfillTickets, backfillTickets),
+		}
+		forwardResults(scope.Ctx, matchRules, logic.BackfillMatches(scope, provider, rules), results)
+	}()
+
+	return results
+}
+
+// registryTicketProvider hands the tickets already read to pick the MatchLogic back to it, ahead of the rest
+type registryTicketProvider struct {
+	tickets         chan matchmaker.Ticket
+	backfillTickets chan matchmaker.BackfillTicket
+}
+
+func (p registryTicketProvider) GetTickets() chan matchmaker.Ticket {
+	return p.tickets
+}
+
+func (p registryTicketProvider) GetBackfillTickets() chan matchmaker.BackfillTicket {
+	return p.backfillTickets
+}
+
+// forwardTickets returns a channel of the first items followed by the items of source, closed once source is closed
+// or the context is done. A nil source counts as closed.
+func forwardTickets[T any](ctx context.Context, first []T, source <-chan T) chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		for _, item := range first {
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if source == nil {
+			return
+		}
+
+		for item := range source {
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// forwardResults sends the results of a MatchLogic on, dropping them once the context is done but still draining
+// the source so that the MatchLogic never blocks on a send. A nil source fails the rules with an Internal error.
+func forwardResults[T any](ctx context.Context, matchRules interface{}, source <-chan T, results chan<- T) {
+	if source == nil {
+		if rules, err := registryRulesOf(matchRules); err == nil {
+			rules.fail(status.Error(codes.Internal, "match logic could not run with the rules"))
+		}
+
+		return
+	}
+
+	for result := range source {
+		select {
+		case results <- result:
+		case <-ctx.Done():
+		}
+	}
+}
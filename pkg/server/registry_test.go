@@ -0,0 +1,182 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	matchfunctiongrpc "matchmaking-function-grpc-plugin-server-go/pkg/pb"
+)
+
+// poolRulesLogic fails to decode the rules, or makes no result channel when it decodes them
+type poolRulesLogic struct {
+	MatchLogic
+	err error
+}
+
+func (l poolRulesLogic) RulesFromJSON(scope *common.Scope, json string) (interface{}, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	return l.MatchLogic.RulesFromJSON(scope, json)
+}
+
+func (l poolRulesLogic) MakeMatches(*common.Scope, TicketProvider, interface{}) <-chan matchmaker.Match {
+	return nil
+}
+
+func TestLogicRegistryRulesErrors(t *testing.T) {
+	newRegistry := func(poolLogic MatchLogic) *LogicRegistry {
+		registry := NewLogicRegistry(New())
+		registry.Register("pool-logic", poolLogic)
+		if err := registry.RegisterPool("pool", "pool-logic"); err != nil {
+			t.Fatal(err)
+		}
+
+		return registry
+	}
+	makeMatches := func(server *MatchFunctionServer, rules string) error {
+		return server.MakeMatches(&fakeMakeMatchesStream{requests: []*matchfunctiongrpc.MakeMatchesRequest{
+			{RequestType: &matchfunctiongrpc.MakeMatchesRequest_Parameters{Parameters: &matchfunctiongrpc.MakeMatchesRequest_MakeMatchesParameters{
+				Rules: &matchfunctiongrpc.Rules{Json: rules},
+			}}},
+			{RequestType: &matchfunctiongrpc.MakeMatchesRequest_Ticket{Ticket: &matchfunctiongrpc.Ticket{
+				TicketId:  "a",
+				MatchPool: "pool",
+				Players:   []*matchfunctiongrpc.Ticket_PlayerData{{PlayerId: "a"}},
+			}}},
+		}})
+	}
+
+	t.Run("invalid rules without a logic field", func(t *testing.T) {
+		server := &MatchFunctionServer{MM: newRegistry(New())}
+		rules := `{"alliance": {"min_number": 2, "max_number": 1}}`
+
+		if _, err := server.MM.RulesFromJSON(testScope(), rules); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected RulesFromJSON to fail with InvalidArgument, got %v", err)
+		}
+		_, err := server.GetStatCodes(context.Background(), &matchfunctiongrpc.GetStatCodesRequest{Rules: &matchfunctiongrpc.Rules{Json: rules}})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected GetStatCodes to fail with InvalidArgument, got %v", err)
+		}
+		if err := makeMatches(server, rules); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected MakeMatches to fail with InvalidArgument, got %v", err)
+		}
+	})
+
+	t.Run("rules rejected by the match pool logic", func(t *testing.T) {
+		poolLogic := poolRulesLogic{MatchLogic: New(), err: status.Error(codes.FailedPrecondition, "rules not supported")}
+		server := &MatchFunctionServer{MM: Chain(newRegistry(poolLogic), LoggingMiddleware())}
+
+		if err := makeMatches(server, `{}`); status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected MakeMatches to fail with the error of the match pool logic, got %v", err)
+		}
+	})
+
+	t.Run("no result channel from the match pool logic", func(t *testing.T) {
+		server := &MatchFunctionServer{MM: newRegistry(poolRulesLogic{MatchLogic: New()})}
+
+		if err := makeMatches(server, `{}`); status.Code(err) != codes.Internal {
+			t.Errorf("expected MakeMatches to fail with Internal, got %v", err)
+		}
+	})
+}
+
+// checkedLogic fails the rules check with its error
+type checkedLogic struct {
+	MatchLogic
+	err error
+}
+
+func (l checkedLogic) CheckRules(interface{}) error {
+	return l.err
+}
+
+func TestChainCheckRules(t *testing.T) {
+	rejected := errors.New("rules rejected")
+	embedding := func(next MatchLogic) MatchLogic {
+		return struct{ MatchLogic }{next}
+	}
+	overriding := func(next MatchLogic) MatchLogic {
+		return checkedLogic{MatchLogic: next}
+	}
+
+	tests := []struct {
+		name        string
+		logic       MatchLogic
+		middlewares []Middleware
+		err         error
+	}{
+		{name: "no middleware", logic: checkedLogic{MatchLogic: New(), err: rejected}, err: rejected},
+		{name: "built-in middlewares", logic: checkedLogic{MatchLogic: New(), err: rejected}, middlewares: []Middleware{LoggingMiddleware(), NewMatchLogicMetrics().Middleware()}, err: rejected},
+		{name: "middleware without CheckRules", logic: checkedLogic{MatchLogic: New(), err: rejected}, middlewares: []Middleware{embedding, embedding}, err: rejected},
+		{name: "middleware with its own CheckRules", logic: checkedLogic{MatchLogic: New(), err: rejected}, middlewares: []Middleware{overriding}},
+		{name: "no RulesChecker wrapped", logic: struct{ MatchLogic }{New()}, middlewares: []Middleware{embedding}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := Chain(tt.logic, tt.middlewares...)
+			if err := checkRules(logic, GameRules{}); !errors.Is(err, tt.err) {
+				t.Errorf("expected the error %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestRegistryRulesLogValue(t *testing.T) {
+	registry := NewLogicRegistry(New())
+	registry.Register("pool-logic", New())
+	if err := registry.RegisterPool("pool", "pool-logic"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		rules    string
+		pool     string // the rules are resolved for the match pool when set
+		contains []string
+	}{
+		{name: "named logic", rules: `{"logic": "pool-logic", "auto_backfill": true}`, contains: []string{"rules.logic=pool-logic", "AutoBackfill:true"}},
+		{name: "default logic", rules: `{"auto_backfill": true}`, contains: []string{"rules.logic=default", "AutoBackfill:true"}},
+		{name: "resolved for a match pool", rules: `{"auto_backfill": true}`, pool: "pool", contains: []string{"rules.matchPool=pool", "AutoBackfill:true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := registry.RulesFromJSON(testScope(), tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.pool != "" {
+				if _, _, err := registry.resolve(testScope(), rules, tt.pool); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out bytes.Buffer
+			slog.New(slog.NewTextHandler(&out, nil)).Info("Retrieved rules", "rules", rules)
+			for _, want := range tt.contains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected %q in the log, got %s", want, out.String())
+				}
+			}
+			if strings.Contains(out.String(), "json") || strings.Contains(out.String(), `\"`) {
+				t.Errorf("expected the rules json to be left out, got %s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+const (
+	// RelaxDistance widens the distance of the matching rule on the relaxation attribute
+	RelaxDistance = "distance"
+	// RelaxRegionLatencyMaxMs raises the latency ceiling of the acceptable regions
+	RelaxRegionLatencyMaxMs = "region_latency_max_ms"
+	// RelaxMinTeamNumber lowers the minimum number of teams of the alliance rule
+	RelaxMinTeamNumber = "min_team_number"
+
+	// ScheduleStep switches to the value of the latest step reached by the ticket age, the steps being in ascending order
+	ScheduleStep = "step"
+	// ScheduleLinear moves the value by a rate per second once the ticket age passes a start, up to a limit
+	ScheduleLinear = "linear"
+)
+
+// RelaxationStep is the value a rule takes once a ticket has waited AfterSec seconds
+type RelaxationStep struct {
+	AfterSec float64 `json:"after_sec"`
+	Value    float64 `json:"value"`
+}
+
+// RelaxationRule loosens a rule as the pivot ticket of a match waits, so long-waiting tickets get matched
+type RelaxationRule struct {
+	Target    string `json:"target"`
+	Attribute string `json:"attribute"` // matching rule attribute when the target is distance
+	Schedule  string `json:"schedule"`
+
+	// step schedule
+	Steps []RelaxationStep `json:"steps"`
+
+	// linear schedule
+	StartAfterSec float64 `json:"start_after_sec"`
+	RatePerSec    float64 `json:"rate_per_sec"` // negative to lower the value
+	Limit         float64 `json:"limit"`        // bound of the value in the direction of the rate, 0 for none
+}
+
+// value returns the relaxed value of the rule after waiting for age, starting from the base value
+func (r RelaxationRule) value(base float64, age time.Duration) float64 {
+	seconds := age.Seconds()
+
+	switch r.Schedule {
+	case ScheduleLinear:
+		if seconds <= r.StartAfterSec {
+			return base
+		}
+		value := base + r.RatePerSec*(seconds-r.StartAfterSec)
+		if r.Limit != 0 {
+			if r.RatePerSec >= 0 {
+				value = math.Min(value, r.Limit)
+			} else {
+				value = math.Max(value, r.Limit)
+			}
+		}
+
+		return value
+	default:
+		value := base
+		for _, step := range r.Steps {
+			if seconds >= step.AfterSec {
+				value = step.Value
+			}
+		}
+
+		return value
+	}
+}
+
+// lowest returns the lowest value the rule can take, starting from the base value, however long a ticket waits
+func (r RelaxationRule) lowest(base float64) float64 {
+	switch r.Schedule {
+	case ScheduleLinear:
+		if r.RatePerSec >= 0 {
+			return base
+		}
+		if r.Limit == 0 {
+			return math.Inf(-1)
+		}
+
+		return math.Min(base, r.Limit)
+	default:
+		value := base
+		for _, step := range r.Steps {
+			value = math.Min(value, step.Value)
+		}
+
+		return value
+	}
+}
+
+// highest returns the highest value the rule can take, starting from the base value, however long a ticket waits
+func (r RelaxationRule) highest(base float64) float64 {
+	switch r.Schedule {
+	case ScheduleLinear:
+		if r.RatePerSec <= 0 {
+			return base
+		}
+		if r.Limit == 0 {
+			return math.Inf(1)
+		}
+
+		return math.Max(base, r.Limit)
+	default:
+		value := base
+		for _, step := range r.Steps {
+			value = math.Max(value, step.Value)
+		}
+
+		return value
+	}
+}
+
+// relaxed returns a copy of the rules loosened by the relaxation rules for a ticket that waited for age
+func (r GameRules) relaxed(age time.Duration) GameRules {
+	if len(r.Relaxation) == 0 {
+		return r
+	}
+
+	relaxed := r
+	relaxed.MatchingRule = slices.Clone(r.MatchingRule)
+	for _, relaxation := range r.Relaxation {
+		switch relaxation.Target {
+		case RelaxDistance:
+			for i, matchingRule := range relaxed.MatchingRule {
+				if matchingRule.Attribute == relaxation.Attribute {
+					relaxed.MatchingRule[i].Distance = relaxation.value(matchingRule.Distance, age)
+				}
+			}
+		case RelaxRegionLatencyMaxMs:
+			// a latency ceiling of 0 accepts any latency already, there is nothing to relax
+			if r.RegionLatencyMaxMs > 0 {
+				relaxed.RegionLatencyMaxMs = int(relaxation.value(float64(r.RegionLatencyMaxMs), age))
+			}
+		case RelaxMinTeamNumber:
+			minTeams, _, _, _ := r.teamBounds()
+			relaxed.AllianceRule.MinNumber = max(int(relaxation.value(float64(minTeams), age)), 1)
+		}
+	}
+
+	return relaxed
+}
+
+// ticketAge returns how long the ticket has been waiting at now.
+// A ticket without a creation time is considered new.
+func ticketAge(ticket matchmaker.Ticket, now time.Time) time.Duration {
+	if ticket.CreatedAt.Unix() <= 0 || ticket.CreatedAt.After(now) {
+		return 0
+	}
+
+	return now.Sub(ticket.CreatedAt)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelaxed(t *testing.T) {
+	steps := RelaxationRule{
+		Target:    RelaxDistance,
+		Attribute: "mmr",
+		Steps:     []RelaxationStep{{AfterSec: 10, Value: 20}, {AfterSec: 30, Value: 50}},
+	}
+	latency := RelaxationRule{Target: RelaxRegionLatencyMaxMs, Schedule: ScheduleLinear, RatePerSec: 10, Limit: 200}
+
+	tests := []struct {
+		name       string
+		rule       GameRules
+		age        time.Duration
+		distance   float64
+		maxLatency int
+	}{
+		{name: "before the first step", rule: GameRules{Relaxation: []RelaxationRule{steps}}, age: 5 * time.Second, distance: 10},
+		{name: "first step", rule: GameRules{Relaxation: []RelaxationRule{steps}}, age: 20 * time.Second, distance: 20},
+		{name: "last step", rule: GameRules{Relaxation: []RelaxationRule{steps}}, age: time.Minute, distance: 50},
+		{name: "latency raised", rule: GameRules{RegionLatencyMaxMs: 100, Relaxation: []RelaxationRule{latency}}, age: 5 * time.Second, distance: 10, maxLatency: 150},
+		{name: "latency up to the limit", rule: GameRules{RegionLatencyMaxMs: 100, Relaxation: []RelaxationRule{latency}}, age: time.Minute, distance: 10, maxLatency: 200},
+		{name: "any latency stays any latency", rule: GameRules{Relaxation: []RelaxationRule{latency}}, age: time.Minute, distance: 10, maxLatency: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.MatchingRule = []MatchingRule{{Attribute: "mmr", Distance: 10}}
+
+			relaxed := tt.rule.relaxed(tt.age)
+			if relaxed.MatchingRule[0].Distance != tt.distance {
+				t.Errorf("expected a distance of %v, got %v", tt.distance, relaxed.MatchingRule[0].Distance)
+			}
+			if relaxed.RegionLatencyMaxMs != tt.maxLatency {
+				t.Errorf("expected a maximum latency of %v, got %v", tt.maxLatency, relaxed.RegionLatencyMaxMs)
+			}
+			if tt.rule.MatchingRule[0].Distance != 10 {
+				t.Error("relaxing changed the original rules")
+			}
+		})
+	}
+}
+
+func TestRulesFromJSONRelaxationSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   string
+		wantErr bool
+	}{
+		{name: "ascending", steps: `[{"after_sec": 10, "value": 20}, {"after_sec": 30, "value": 50}]`},
+		{name: "descending", steps: `[{"after_sec": 30, "value": 50}, {"after_sec": 10, "value": 20}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := `{"matching_rule": [{"attribute": "mmr", "distance": 10}], "relaxation": [{"target": "distance", "attribute": "mmr", "steps": ` + tt.steps + `}]}`
+
+			_, err := MatchMaker{}.RulesFromJSON(testScope(), rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
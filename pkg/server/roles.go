@@ -0,0 +1,144 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"slices"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+)
+
+// RoleRule requires every team to be made of a given number of players of each role
+type RoleRule struct {
+	Attribute   string         `json:"attribute"`   // player attribute with the player's roles, in order of preference
+	Composition map[string]int `json:"composition"` // number of players of each role on every team
+}
+
+// enabled reports whether the rule constrains the team composition
+func (r RoleRule) enabled() bool {
+	return len(r.Composition) > 0
+}
+
+// slots returns one entry per player required by the composition, sorted by role
+func (r RoleRule) slots() []string {
+	roles := make([]string, 0, len(r.Composition))
+	for role := range r.Composition {
+		roles = append(roles, role)
+	}
+	slices.Sort(roles)
+
+	var slots []string
+	for _, role := range roles {
+		for i := 0; i < r.Composition[role]; i++ {
+			slots = append(slots, role)
+		}
+	}
+
+	return slots
+}
+
+// playerRoles returns the roles the player is willing to play, in order of preference.
+// The attribute holds either a single role or a list of roles.
+func (r RoleRule) playerRoles(player playerdata.PlayerData) []string {
+	switch value := player.Attributes[r.Attribute].(type) {
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	case []interface{}:
+		var roles []string
+		for _, role := range value {
+			if s, ok := role.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+
+		return roles
+	default:
+		return nil
+	}
+}
+
+// assignRoles matches the players of the team to the role slots of the composition, trying the roles of each
+// player in order of preference and reassigning earlier players when that makes room for more.
+// It returns the number of slots filled and the role of every player, where a player without a slot keeps the
+// first role they prefer.
+func (r RoleRule) assignRoles(team []matchmaker.Ticket) (int, map[playerdata.ID]string) {
+	var players []playerdata.PlayerData
+	for _, ticket := range team {
+		players = append(players, ticket.Players...)
+	}
+
+	slots := r.slots()
+	owners := make([]int, len(slots))
+	for i := range owners {
+		owners[i] = -1
+	}
+
+	var assign func(player int, visited []bool) bool
+	assign = func(player int, visited []bool) bool {
+		for _, role := range r.playerRoles(players[player]) {
+			for slot, slotRole := range slots {
+				if slotRole != role || visited[slot] {
+					continue
+				}
+				visited[slot] = true
+				if owners[slot] == -1 || assign(owners[slot], visited) {
+					owners[slot] = player
+
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	filled := 0
+	for player := range players {
+		if assign(player, make([]bool, len(slots))) {
+			filled++
+		}
+	}
+
+	roles := make(map[playerdata.ID]string, len(players))
+	for _, player := range players {
+		if preferred := r.playerRoles(player); len(preferred) > 0 {
+			roles[player.PlayerID] = preferred[0]
+		}
+	}
+	for slot, owner := range owners {
+		if owner != -1 {
+			roles[players[owner].PlayerID] = slots[slot]
+		}
+	}
+
+	return filled, roles
+}
+
+// canComplete reports whether the team can still reach the composition with players added, given that the players
+// beyond the composition take any of the maxPlayers places left over
+func (r RoleRule) canComplete(team []matchmaker.Ticket, maxPlayers int) bool {
+	if !r.enabled() {
+		return true
+	}
+
+	filled, _ := r.assignRoles(team)
+	spare := maxPlayers - len(r.slots())
+
+	return filled >= ticketPlayerCount(team)-spare
+}
+
+// isComplete reports whether every role slot of the composition is filled by a player of the team
+func (r RoleRule) isComplete(team []matchmaker.Ticket) bool {
+	if !r.enabled() {
+		return true
+	}
+
+	filled, _ := r.assignRoles(team)
+
+	return filled == len(r.slots())
+}
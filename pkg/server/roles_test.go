@@ -0,0 +1,162 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+// roleTicket returns a ticket with one player per roles value, each stored in the role attribute of the player
+func roleTicket(id string, roles ...interface{}) matchmaker.Ticket {
+	ticket := testTicket(id, time.Now(), make([]float64, len(roles))...)
+	for i, role := range roles {
+		ticket.Players[i].Attributes["role"] = role
+	}
+
+	return ticket
+}
+
+func TestAssignRoles(t *testing.T) {
+	rule := RoleRule{Attribute: "role", Composition: map[string]int{"tank": 1, "healer": 1, "dps": 1}}
+
+	tests := []struct {
+		name   string
+		team   []matchmaker.Ticket
+		filled int
+		roles  map[string]string
+	}{
+		{
+			name:   "one player per role",
+			team:   []matchmaker.Ticket{roleTicket("a", "tank", "healer"), roleTicket("b", "dps")},
+			filled: 3,
+			roles:  map[string]string{"a-0": "tank", "a-1": "healer", "b-0": "dps"},
+		},
+		{
+			name:   "second preference taken",
+			team:   []matchmaker.Ticket{roleTicket("a", "tank", []interface{}{"tank", "dps"}, "healer")},
+			filled: 3,
+			roles:  map[string]string{"a-0": "tank", "a-1": "dps", "a-2": "healer"},
+		},
+		{
+			name:   "earlier player moved to make room",
+			team:   []matchmaker.Ticket{roleTicket("a", []string{"tank", "dps"}, "tank", "healer")},
+			filled: 3,
+			roles:  map[string]string{"a-0": "dps", "a-1": "tank", "a-2": "healer"},
+		},
+		{
+			name:   "role taken twice",
+			team:   []matchmaker.Ticket{roleTicket("a", "tank", "tank", "healer")},
+			filled: 2,
+			roles:  map[string]string{"a-0": "tank", "a-1": "tank", "a-2": "healer"},
+		},
+		{
+			name:   "player without a role",
+			team:   []matchmaker.Ticket{roleTicket("a", "tank", nil, "healer")},
+			filled: 2,
+			roles:  map[string]string{"a-0": "tank", "a-2": "healer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filled, roles := rule.assignRoles(tt.team)
+			if filled != tt.filled {
+				t.Errorf("expected %d slots filled, got %d", tt.filled, filled)
+			}
+			if fmt.Sprint(roles) != fmt.Sprint(tt.roles) {
+				t.Errorf("expected roles %v, got %v", tt.roles, roles)
+			}
+		})
+	}
+}
+
+func TestBuildMatchRoles(t *testing.T) {
+	rule := GameRules{
+		AllianceRule: AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 2, PlayerMaxNumber: 2},
+		Role:         RoleRule{Attribute: "role", Composition: map[string]int{"tank": 1, "healer": 1}},
+	}
+
+	tests := []struct {
+		name    string
+		tickets []matchmaker.Ticket
+		roles   map[string]interface{} // nil when no match is made
+	}{
+		{
+			name:    "every team composed",
+			tickets: []matchmaker.Ticket{roleTicket("a", "tank"), roleTicket("b", "tank"), roleTicket("c", "healer"), roleTicket("d", "healer")},
+			roles:   map[string]interface{}{"a-0": "tank", "b-0": "tank", "c-0": "healer", "d-0": "healer"},
+		},
+		{
+			name:    "party split over the roles of its team",
+			tickets: []matchmaker.Ticket{roleTicket("a", "tank", "healer"), roleTicket("b", "healer"), roleTicket("c", "tank")},
+			roles:   map[string]interface{}{"a-0": "tank", "a-1": "healer", "b-0": "healer", "c-0": "tank"},
+		},
+		{
+			name:    "too few healers",
+			tickets: []matchmaker.Ticket{roleTicket("a", "tank"), roleTicket("b", "tank"), roleTicket("c", "healer"), roleTicket("d", "tank")},
+		},
+		{
+			name:    "party of one role",
+			tickets: []matchmaker.Ticket{roleTicket("a", "tank", "tank"), roleTicket("b", "healer"), roleTicket("c", "healer")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(chan matchmaker.Match, len(tt.tickets))
+			var unmatched []matchmaker.Ticket
+			for _, ticket := range tt.tickets {
+				unmatched = buildMatch(testScope(), ticket, unmatched, rule, results)
+			}
+			close(results)
+
+			match, ok := <-results
+			if ok != (tt.roles != nil) {
+				t.Fatalf("expected a match %v, got %v", tt.roles != nil, ok)
+			}
+			if !ok {
+				if len(unmatched) != len(tt.tickets) {
+					t.Errorf("expected %d unmatched tickets, got %d", len(tt.tickets), len(unmatched))
+				}
+
+				return
+			}
+			if fmt.Sprint(match.MatchAttributes["roles"]) != fmt.Sprint(tt.roles) {
+				t.Errorf("expected roles %v, got %v", tt.roles, match.MatchAttributes["roles"])
+			}
+		})
+	}
+}
+
+func TestRulesFromJSONRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "no role rule", rules: `{}`},
+		{name: "composition", rules: `{"role": {"attribute": "role", "composition": {"tank": 1, "dps": 1}}}`},
+		{name: "no attribute", rules: `{"role": {"composition": {"tank": 1}}}`, wantErr: true},
+		{name: "negative count", rules: `{"role": {"attribute": "role", "composition": {"tank": -1}}}`, wantErr: true},
+		{
+			name:    "more roles than players",
+			rules:   `{"alliance": {"player_min_number": 1, "player_max_number": 1}, "role": {"attribute": "role", "composition": {"tank": 1, "dps": 1}}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MatchMaker{}.RulesFromJSON(testScope(), tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	matchfunction "matchmaking-function-grpc-plugin-server-go/pkg/pb"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+
+	pie_ "github.com/elliotchance/pie/v2"
+)
+
+// ticketTeams is a set of teams, each holding the whole tickets placed onto it
+type ticketTeams [][]matchmaker.Ticket
+
+// playerCount returns the number of players on the team at the given index
+func (t ticketTeams) playerCount(index int) int {
+	return ticketPlayerCount(t[index])
+}
+
+// ticketPlayerCount returns the number of players over all the tickets
+func ticketPlayerCount(tickets []matchmaker.Ticket) int {
+	count := 0
+	for _, ticket := range tickets {
+		count += len(ticket.Players)
+	}
+
+	return count
+}
+
+// packTeams distributes the tickets onto numTeams teams using first-fit decreasing bin packing. Tickets are parties
+// and are never split: the largest tickets are placed first, each onto the least populated team that still has room
+// for all of its players and can still reach its role composition. It returns nil when a ticket fits onto no team.
+func packTeams(tickets []matchmaker.Ticket, numTeams int, limits teamLimits) ticketTeams {
+	sorted := slices.Clone(tickets)
+	slices.SortStableFunc(sorted, func(a, b matchmaker.Ticket) int {
+		return len(b.Players) - len(a.Players)
+	})
+
+	teams := make(ticketTeams, numTeams)
+	for _, ticket := range sorted {
+		target := -1
+		for i := range teams {
+			if !limits.fits(append(slices.Clone(teams[i]), ticket)) {
+				continue
+			}
+			if target == -1 || teams.playerCount(i) < teams.playerCount(target) {
+				target = i
+			}
+		}
+
+		if target == -1 {
+			return nil
+		}
+		teams[target] = append(teams[target], ticket)
+	}
+
+	return teams
+}
+
+// ticketGroup collects the tickets that are allowed to play together in a single match
+type ticketGroup struct {
+	rule    GameRules
+	tickets []matchmaker.Ticket
+	regions map[string]struct{} // regions acceptable to every ticket, nil while any region is
+}
+
+// fits reports whether the ticket has players, leaves the group within capacity players and is accepted by the group
+func (g *ticketGroup) fits(ticket matchmaker.Ticket, capacity int) bool {
+	if len(ticket.Players) == 0 || ticketPlayerCount(g.tickets)+len(ticket.Players) > capacity {
+		return false
+	}
+
+	return g.accepts(ticket)
+}
+
+// accepts reports whether the ticket can join the tickets already in the group.
+// The first ticket of the group is the pivot the matching rules are measured from.
+func (g *ticketGroup) accepts(ticket matchmaker.Ticket) bool {
+	if len(g.tickets) > 0 && !g.rule.matchesPivot(g.tickets[0], ticket) {
+		return false
+	}
+
+	regions := intersectRegions(g.regions, acceptableRegions(ticket, g.rule.RegionLatencyMaxMs))
+	if regions != nil && len(regions) == 0 {
+		return false
+	}
+
+	for _, other := range g.tickets {
+		if ticketsConflict(other, ticket, g.rule) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// add puts the ticket in the group, the ticket must be accepted by the group first
+func (g *ticketGroup) add(ticket matchmaker.Ticket) {
+	g.tickets = append(g.tickets, ticket)
+	g.regions = intersectRegions(g.regions, acceptableRegions(ticket, g.rule.RegionLatencyMaxMs))
+}
+
+// maxPivots is the number of oldest tickets tried as the pivot of a match, next to the required ticket. Trying every
+// ticket would cost a pass over the whole pool per pivot each time a ticket arrives.
+const maxPivots = 8
+
+// findTeams looks for the largest number of teams, within the alliance rule, that the tickets can fill up to the
+// minimum team size, with the ticket at the required index among them. The tickets are sorted oldest first, and the
+// maxPivots oldest tickets then the required ticket are tried in turn as the pivot of the match, with the rules
+// relaxed for how long the pivot has waited at now, unless the required ticket can not join it. The required ticket
+// is taken next, then the other tickets oldest first, each skipped when it does not fit next to the ones already
+// taken, either for lack of room, for lack of a region acceptable to all of them, for being too far from the pivot
+// according to the matching rules, or for a block or exclusion between players.
+// It returns the sorted indexes of the tickets used, the teams and the relaxed rules they were built with, or nil
+// teams when no match can be made.
+func findTeams(tickets []matchmaker.Ticket, required int, rule GameRules, now time.Time) ([]int, ticketTeams, GameRules) {
+	pivots := make([]int, 0, maxPivots+1)
+	for pivot := range min(len(tickets), maxPivots) {
+		pivots = append(pivots, pivot)
+	}
+	if required >= maxPivots {
+		pivots = append(pivots, required)
+	}
+
+	for _, pivot := range pivots {
+		relaxed := rule.relaxed(ticketAge(tickets[pivot], now))
+		minTeams, maxTeams, minPlayers, maxPlayers := relaxed.teamBounds()
+		limits := teamLimits{minPlayers: minPlayers, maxPlayers: maxPlayers, roles: relaxed.Role}
+
+		// most pivots are too far from the required ticket, which is cheap to check before filling any team
+		if pivot != required {
+			if !relaxed.matchesPivot(tickets[pivot], tickets[required]) {
+				continue
+			}
+			group := ticketGroup{rule: relaxed}
+			group.add(tickets[pivot])
+			if !group.fits(tickets[required], maxTeams*maxPlayers) {
+				continue
+			}
+		}
+
+		for numTeams := maxTeams; numTeams >= minTeams; numTeams-- {
+			used, teams := fillTeams(tickets, pivot, required, numTeams, limits, relaxed)
+			if teams == nil {
+				continue
+			}
+
+			teams = balanceTeams(teams, relaxed.Balance, limits)
+			if limits.allows(teams) {
+				slices.Sort(used)
+
+				return used, teams, relaxed
+			}
+		}
+	}
+
+	return nil, nil, rule
+}
+
+// fillTeams takes as many tickets as possible next to the pivot and the required ticket to fill numTeams teams.
+// It returns the indexes of the tickets taken and their teams, or nil teams when the pivot or the required ticket
+// fits nowhere.
+func fillTeams(tickets []matchmaker.Ticket, pivot int, required int, numTeams int, limits teamLimits, rule GameRules) ([]int, ticketTeams) {
+	capacity := numTeams * limits.maxPlayers
+
+	order := make([]int, 0, len(tickets))
+	order = append(order, pivot)
+	if required != pivot {
+		order = append(order, required)
+	}
+	for i := range tickets {
+		if i != pivot && i != required {
+			order = append(order, i)
+		}
+	}
+
+	var used []int
+	var teams ticketTeams
+	group := ticketGroup{rule: rule}
+	for _, i := range order {
+		ticket := tickets[i]
+		if !group.fits(ticket, capacity) {
+			if i == pivot || i == required {
+				return nil, nil
+			}
+
+			continue
+		}
+
+		packed := packTeams(append(slices.Clone(group.tickets), ticket), numTeams, limits)
+		if packed == nil {
+			if i == pivot || i == required {
+				return nil, nil
+			}
+
+			continue
+		}
+
+		used = append(used, i)
+		group.add(ticket)
+		teams = packed
+		if ticketPlayerCount(group.tickets) == capacity {
+			break
+		}
+	}
+
+	return used, teams
+}
+
+// isFull reports whether every team has reached the maximum team size
+func (t ticketTeams) isFull(rule GameRules) bool {
+	_, maxTeams, _, maxPlayers := rule.teamBounds()
+	if len(t) < maxTeams {
+		return false
+	}
+	for i := range t {
+		if t.playerCount(i) < maxPlayers {
+			return false
+		}
+	}
+
+	return true
+}
+
+// toMatch converts the teams into a match with one matchmaker.Team per team and the team assignment attribute
+func (t ticketTeams) toMatch(rule GameRules, backfill bool) matchmaker.Match {
+	match := matchmaker.Match{
+		Backfill: backfill,
+	}
+
+	assignment := make(map[string]interface{}, len(t))
+	for i, tickets := range t {
+		var players []playerdata.PlayerData
+		for _, ticket := range tickets {
+			players = append(players, ticket.Players...)
+		}
+
+		teamID := common.GenerateUUID()
+		match.Teams = append(match.Teams, matchmaker.Team{
+			UserIDs: pie_.Map(players, playerdata.ToID),
+			Parties: matchfunction.PlayerDataToParties(players),
+			TeamID:  teamID,
+		})
+		match.Tickets = append(match.Tickets, tickets...)
+		assignment[fmt.Sprintf("team-%d", i+1)] = []string{teamID}
+	}
+	match.RegionPreference = regionPreference(match.Tickets, rule)
+	match.MatchAttributes = map[string]interface{}{
+		"assignment": assignment,
+	}
+
+	if rule.Role.enabled() {
+		roles := make(map[string]interface{})
+		for _, tickets := range t {
+			_, teamRoles := rule.Role.assignRoles(tickets)
+			for playerID, role := range teamRoles {
+				roles[playerdata.IDToString(playerID)] = role
+			}
+		}
+		match.MatchAttributes["roles"] = roles
+	}
+
+	if rule.Balance.Attribute != "" {
+		teamSkill := make(map[string]interface{}, len(t))
+		for i, tickets := range t {
+			teamSkill[fmt.Sprintf("team-%d", i+1)] = rule.Balance.teamSkill(tickets)
+		}
+		match.MatchAttributes["team_skill"] = teamSkill
+	}
+
+	return match
+}
+
+// insertByAge inserts the ticket among the tickets, which are sorted oldest first, after the tickets as old as it.
+// It returns the tickets and the index of the inserted ticket.
+func insertByAge(tickets []matchmaker.Ticket, ticket matchmaker.Ticket, now time.Time) ([]matchmaker.Ticket, int) {
+	age := ticketAge(ticket, now)
+	index := len(tickets)
+	for i, other := range tickets {
+		if ticketAge(other, now) < age {
+			index = i
+
+			break
+		}
+	}
+
+	return slices.Insert(tickets, index, ticket), index
+}
+
+// removeTickets returns the tickets not listed in the sorted indexes, keeping their order
+func removeTickets(tickets []matchmaker.Ticket, indexes []int) []matchmaker.Ticket {
+	remaining := make([]matchmaker.Ticket, 0, len(tickets)-len(indexes))
+	for i, ticket := range tickets {
+		if _, found := slices.BinarySearch(indexes, i); !found {
+			remaining = append(remaining, ticket)
+		}
+	}
+
+	return remaining
+}
@@ -0,0 +1,363 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+)
+
+func testScope() *common.Scope {
+	scope := common.ChildScopeFromRemoteScope(context.Background(), "test")
+	scope.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return scope
+}
+
+// testTicket returns a ticket with one player per mmr value, all in the same party
+func testTicket(id string, createdAt time.Time, mmrs ...float64) matchmaker.Ticket {
+	ticket := matchmaker.Ticket{TicketID: id, CreatedAt: createdAt}
+	for i, mmr := range mmrs {
+		ticket.Players = append(ticket.Players, playerdata.PlayerData{
+			PlayerID:   playerdata.ID(fmt.Sprintf("%s-%d", id, i)),
+			PartyID:    "party-" + id,
+			Attributes: map[string]interface{}{"mmr": mmr},
+		})
+	}
+
+	return ticket
+}
+
+func TestBuildMatchTeams(t *testing.T) {
+	now := time.Now()
+	alliance := func(minTeams, maxTeams, minPlayers, maxPlayers int) GameRules {
+		return GameRules{AllianceRule: AllianceRule{MinNumber: minTeams, MaxNumber: maxTeams, PlayerMinNumber: minPlayers, PlayerMaxNumber: maxPlayers}}
+	}
+
+	tests := []struct {
+		name       string
+		rule       GameRules
+		partySizes []int
+		teamSizes  [][]int // sorted team sizes of each match made
+		unmatched  int
+	}{
+		{
+			name:       "solo players fill two teams",
+			rule:       alliance(2, 2, 2, 2),
+			partySizes: []int{1, 1, 1, 1},
+			teamSizes:  [][]int{{2, 2}},
+		},
+		{
+			name:       "party stays on one team",
+			rule:       alliance(2, 2, 2, 2),
+			partySizes: []int{2, 1, 1},
+			teamSizes:  [][]int{{2, 2}},
+		},
+		{
+			name:       "parties that can not be packed wait",
+			rule:       alliance(2, 2, 3, 3),
+			partySizes: []int{2, 2, 2},
+			unmatched:  3,
+		},
+		{
+			name:       "party skipped until it fits",
+			rule:       alliance(2, 2, 3, 3),
+			partySizes: []int{2, 2, 2, 1, 1},
+			teamSizes:  [][]int{{3, 3}},
+			unmatched:  1,
+		},
+		{
+			name:       "party larger than a team never matches",
+			rule:       alliance(2, 2, 1, 2),
+			partySizes: []int{3, 1, 1},
+			teamSizes:  [][]int{{1, 1}},
+			unmatched:  1,
+		},
+		{
+			name:       "three teams",
+			rule:       alliance(3, 3, 1, 1),
+			partySizes: []int{1, 1, 1},
+			teamSizes:  [][]int{{1, 1, 1}},
+		},
+		{
+			name:       "several matches in a row",
+			rule:       alliance(2, 2, 1, 1),
+			partySizes: []int{1, 1, 1, 1, 1},
+			teamSizes:  [][]int{{1, 1}, {1, 1}},
+			unmatched:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(chan matchmaker.Match, len(tt.partySizes))
+			var unmatched []matchmaker.Ticket
+			for i, size := range tt.partySizes {
+				mmrs := make([]float64, size)
+				ticket := testTicket(fmt.Sprint(i), now.Add(-time.Duration(len(tt.partySizes)-i)*time.Second), mmrs...)
+				unmatched = buildMatch(testScope(), ticket, unmatched, tt.rule, results)
+			}
+			close(results)
+
+			var teamSizes [][]int
+			for match := range results {
+				_, _, minPlayers, maxPlayers := tt.rule.teamBounds()
+				var sizes []int
+				playerTeam := make(map[playerdata.ID]int)
+				for i, team := range match.Teams {
+					sizes = append(sizes, len(team.UserIDs))
+					if len(team.UserIDs) < minPlayers || len(team.UserIDs) > maxPlayers {
+						t.Errorf("team %d has %d players, expected %d to %d", i, len(team.UserIDs), minPlayers, maxPlayers)
+					}
+					for _, playerID := range team.UserIDs {
+						playerTeam[playerID] = i
+					}
+				}
+				for _, ticket := range match.Tickets {
+					for _, player := range ticket.Players {
+						team, found := playerTeam[player.PlayerID]
+						if !found || team != playerTeam[ticket.Players[0].PlayerID] {
+							t.Errorf("ticket %s is not whole on one team", ticket.TicketID)
+						}
+					}
+				}
+				slices.Sort(sizes)
+				teamSizes = append(teamSizes, sizes)
+			}
+
+			if fmt.Sprint(teamSizes) != fmt.Sprint(tt.teamSizes) {
+				t.Errorf("expected matches with team sizes %v, got %v", tt.teamSizes, teamSizes)
+			}
+			if len(unmatched) != tt.unmatched {
+				t.Errorf("expected %d unmatched tickets, got %d", tt.unmatched, len(unmatched))
+			}
+		})
+	}
+}
+
+func TestBuildMatchOldestPivot(t *testing.T) {
+	now := time.Now()
+	rule := GameRules{
+		AllianceRule: AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 1, PlayerMaxNumber: 1},
+		MatchingRule: []MatchingRule{{Attribute: "mmr", Distance: 10}},
+	}
+
+	// the new ticket is within the distance of both waiting tickets, the one that waited longer arrived last
+	arrivals := []matchmaker.Ticket{
+		testTicket("recent", now.Add(-time.Second), 0),
+		testTicket("oldest", now.Add(-time.Minute), 20),
+		testTicket("new", now, 10),
+	}
+
+	results := make(chan matchmaker.Match, 1)
+	var unmatched []matchmaker.Ticket
+	for _, ticket := range arrivals {
+		unmatched = buildMatch(testScope(), ticket, unmatched, rule, results)
+	}
+	close(results)
+
+	match, ok := <-results
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	var matched []string
+	for _, ticket := range match.Tickets {
+		matched = append(matched, ticket.TicketID)
+	}
+	slices.Sort(matched)
+	if fmt.Sprint(matched) != "[new oldest]" {
+		t.Errorf("expected the oldest ticket to be matched with the new one, got %v", matched)
+	}
+	if len(unmatched) != 1 || unmatched[0].TicketID != "recent" {
+		t.Errorf("expected the recent ticket to wait, got %v", unmatched)
+	}
+}
+
+func TestBalanceTeams(t *testing.T) {
+	now := time.Now()
+	solos := func(mmrs ...float64) []matchmaker.Ticket {
+		var tickets []matchmaker.Ticket
+		for i, mmr := range mmrs {
+			tickets = append(tickets, testTicket(fmt.Sprint(i), now, mmr))
+		}
+
+		return tickets
+	}
+
+	tests := []struct {
+		name      string
+		tickets   []matchmaker.Ticket
+		numTeams  int
+		players   int
+		strategy  string
+		maxSpread float64
+	}{
+		{name: "snake", tickets: solos(100, 200, 300, 400), numTeams: 2, players: 2, strategy: BalanceSnake, maxSpread: 0},
+		{name: "exhaustive", tickets: solos(100, 200, 300, 400), numTeams: 2, players: 2, strategy: BalanceExhaustive, maxSpread: 0},
+		{name: "local search", tickets: solos(100, 200, 300, 400), numTeams: 2, players: 2, strategy: BalanceLocalSearch, maxSpread: 0},
+		{name: "auto", tickets: solos(100, 200, 300, 400, 500, 600), numTeams: 3, players: 2, maxSpread: 0},
+		{
+			name:      "party kept whole",
+			tickets:   append(solos(500, 100), testTicket("party", now, 300, 300)),
+			numTeams:  2,
+			players:   2,
+			maxSpread: 0,
+		},
+		{
+			name:      "uneven skill",
+			tickets:   solos(1000, 100, 100, 100),
+			numTeams:  2,
+			players:   2,
+			strategy:  BalanceExhaustive,
+			maxSpread: 900,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := BalanceRule{Attribute: "mmr", Strategy: tt.strategy}
+			limits := teamLimits{minPlayers: tt.players, maxPlayers: tt.players}
+			teams := packTeams(tt.tickets, tt.numTeams, limits)
+			if teams == nil {
+				t.Fatal("tickets could not be packed")
+			}
+
+			balanced := balanceTeams(teams, rule, limits)
+			if !limits.allows(balanced) {
+				t.Errorf("balanced teams break the team sizes: %v", balanced)
+			}
+			if spread := rule.spread(balanced); spread > tt.maxSpread {
+				t.Errorf("expected a spread of at most %v, got %v", tt.maxSpread, spread)
+			}
+
+			var placed []string
+			for _, team := range balanced {
+				for _, ticket := range team {
+					placed = append(placed, ticket.TicketID)
+				}
+			}
+			slices.Sort(placed)
+			if len(placed) != len(tt.tickets) || len(slices.Compact(placed)) != len(tt.tickets) {
+				t.Errorf("expected every ticket placed once, got %v", placed)
+			}
+		})
+	}
+}
+
+func BenchmarkBuildMatchUnmatchable(b *testing.B) {
+	rule := GameRules{
+		AllianceRule: AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 5, PlayerMaxNumber: 5},
+		MatchingRule: []MatchingRule{{Attribute: "mmr", Distance: 10}},
+	}
+	scope := testScope()
+	now := time.Now()
+
+	for _, size := range []int{400, 800} {
+		tickets := make([]matchmaker.Ticket, size)
+		for i := range tickets {
+			// every ticket is further than the distance from every other one, so no match can ever be made
+			tickets[i] = testTicket(fmt.Sprint(i), now.Add(-time.Duration(i)*time.Second), float64(i*100))
+		}
+
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				var unmatched []matchmaker.Ticket
+				for _, ticket := range tickets {
+					unmatched = buildMatch(scope, ticket, unmatched, rule, nil)
+				}
+				if len(unmatched) != size {
+					b.Fatalf("expected no match, got %d unmatched tickets", len(unmatched))
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/ticket")
+		})
+	}
+}
+
+func TestBuildMatchNewPivot(t *testing.T) {
+	now := time.Now()
+	rule := GameRules{
+		AllianceRule: AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 1, PlayerMaxNumber: 1},
+		MatchingRule: []MatchingRule{{Attribute: "mmr", Distance: 10}},
+	}
+
+	// the waiting tickets tried as pivots are all too far from the new ticket, which can still pivot a match
+	var unmatched []matchmaker.Ticket
+	results := make(chan matchmaker.Match, 1)
+	for i := range maxPivots {
+		unmatched = buildMatch(testScope(), testTicket(fmt.Sprint(i), now.Add(-time.Minute), float64(1000+100*i)), unmatched, rule, results)
+	}
+	unmatched = buildMatch(testScope(), testTicket("waiting", now.Add(-time.Second), 0), unmatched, rule, results)
+	unmatched = buildMatch(testScope(), testTicket("new", now, 10), unmatched, rule, results)
+	close(results)
+
+	match, ok := <-results
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	var matched []string
+	for _, ticket := range match.Tickets {
+		matched = append(matched, ticket.TicketID)
+	}
+	slices.Sort(matched)
+	if fmt.Sprint(matched) != "[new waiting]" {
+		t.Errorf("expected the waiting ticket to be matched with the new one, got %v", matched)
+	}
+	if len(unmatched) != maxPivots {
+		t.Errorf("expected %d unmatched tickets, got %d", maxPivots, len(unmatched))
+	}
+}
+
+// BenchmarkBuildMatchUnpackable feeds a tick of parties of four to a five versus five alliance without matching
+// rules, where no two parties ever fit on one team and every ticket is left waiting
+func BenchmarkBuildMatchUnpackable(b *testing.B) {
+	now := time.Now()
+	rule := GameRules{AllianceRule: AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 5, PlayerMaxNumber: 5}}
+
+	tickets := make([]matchmaker.Ticket, 400)
+	for i := range tickets {
+		tickets[i] = testTicket(fmt.Sprint(i), now.Add(-time.Duration(len(tickets)-i)*time.Second), 0, 0, 0, 0)
+	}
+
+	scope := testScope()
+	results := make(chan matchmaker.Match)
+	for b.Loop() {
+		var unmatched []matchmaker.Ticket
+		for _, ticket := range tickets {
+			unmatched = buildMatch(scope, ticket, unmatched, rule, results)
+		}
+		if len(unmatched) != len(tickets) {
+			b.Fatalf("expected %d unmatched tickets, got %d", len(tickets), len(unmatched))
+		}
+	}
+}
+
+func TestBuildMatchCancelled(t *testing.T) {
+	now := time.Now()
+	rule := GameRules{AllianceRule: AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 1, PlayerMaxNumber: 1}}
+
+	scope := testScope()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scope.Ctx = ctx
+
+	// nobody reads the results, the match can only be dropped
+	results := make(chan matchmaker.Match)
+	unmatched := buildMatch(scope, testTicket("a", now.Add(-time.Second), 0), nil, rule, results)
+	unmatched = buildMatch(scope, testTicket("b", now, 0), unmatched, rule, results)
+
+	if len(unmatched) != 2 {
+		t.Errorf("expected the tickets of the dropped match to remain unmatched, got %v", unmatched)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+/*
+TicketSnapshot holds every ticket and backfill ticket of a tick, read at once from a TicketProvider, and answers
+indexed queries over the tickets, for match logic that needs to see the whole pool instead of one ticket at a time.
+It is itself a TicketProvider that hands the same tickets out again, so it can be passed on to other match logic.
+
+Query results keep the order the tickets were received in. Ticket ages are measured at the time the snapshot was
+taken. A TicketSnapshot is safe for concurrent use.
+*/
+type TicketSnapshot struct {
+	tickets         []matchmaker.Ticket
+	backfillTickets []matchmaker.BackfillTicket
+	takenAt         time.Time
+
+	byAge       sortedIndex[time.Duration]
+	byPartySize sortedIndex[int]
+	byRegion    map[string]sortedIndex[int64]
+	anyRegion   []int
+
+	lock        sync.Mutex
+	byAttribute map[attributeKey]sortedIndex[float64]
+}
+
+type attributeKey struct {
+	attribute   string
+	aggregation string
+}
+
+// NewTicketSnapshot reads the tickets and the backfill tickets of the TicketProvider until both channels are closed.
+// It returns the error of the context when the context is done first.
+func NewTicketSnapshot(ctx context.Context, ticketProvider TicketProvider) (*TicketSnapshot, error) {
+	var tickets []matchmaker.Ticket
+	var backfillTickets []matchmaker.BackfillTicket
+	nextTicket := ticketProvider.GetTickets()
+	nextBackfillTicket := ticketProvider.GetBackfillTickets()
+
+	// a closed channel is set to nil so that it is no longer selected
+	for nextTicket != nil || nextBackfillTicket != nil {
+		select {
+		case ticket, ok := <-nextTicket:
+			if !ok {
+				nextTicket = nil
+
+				continue
+			}
+			tickets = append(tickets, ticket)
+		case backfillTicket, ok := <-nextBackfillTicket:
+			if !ok {
+				nextBackfillTicket = nil
+
+				continue
+			}
+			backfillTickets = append(backfillTickets, backfillTicket)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return newTicketSnapshot(tickets, backfillTickets, time.Now()), nil
+}
+
+func newTicketSnapshot(tickets []matchmaker.Ticket, backfillTickets []matchmaker.BackfillTicket, takenAt time.Time) *TicketSnapshot {
+	s := &TicketSnapshot{
+		tickets:         tickets,
+		backfillTickets: backfillTickets,
+		takenAt:         takenAt,
+		byRegion:        make(map[string]sortedIndex[int64]),
+		byAttribute:     make(map[attributeKey]sortedIndex[float64]),
+	}
+
+	positions := make([]int, len(tickets))
+	regionPositions := make(map[string][]int)
+	for i, ticket := range tickets {
+		positions[i] = i
+		if len(ticket.Latencies) == 0 {
+			s.anyRegion = append(s.anyRegion, i)
+		}
+		for region := range ticket.Latencies {
+			regionPositions[region] = append(regionPositions[region], i)
+		}
+	}
+
+	s.byAge = newSortedIndex(positions, func(i int) time.Duration { return ticketAge(tickets[i], takenAt) })
+	s.byPartySize = newSortedIndex(positions, func(i int) int { return len(tickets[i].Players) })
+	for region, positions := range regionPositions {
+		s.byRegion[region] = newSortedIndex(positions, func(i int) int64 { return tickets[i].Latencies[region] })
+	}
+
+	return s
+}
+
+// GetTickets returns a channel of the tickets of the snapshot, closed after the last one
+func (s *TicketSnapshot) GetTickets() chan matchmaker.Ticket {
+	return bufferedChannel(s.tickets)
+}
+
+// GetBackfillTickets returns a channel of the backfill tickets of the snapshot, closed after the last one
+func (s *TicketSnapshot) GetBackfillTickets() chan matchmaker.BackfillTicket {
+	return bufferedChannel(s.backfillTickets)
+}
+
+// Tickets returns all the tickets of the snapshot
+func (s *TicketSnapshot) Tickets() []matchmaker.Ticket {
+	return slices.Clone(s.tickets)
+}
+
+// BackfillTickets returns all the backfill tickets of the snapshot
+func (s *TicketSnapshot) BackfillTickets() []matchmaker.BackfillTicket {
+	return slices.Clone(s.backfillTickets)
+}
+
+// TakenAt returns the time the snapshot was taken, which ticket ages are measured at
+func (s *TicketSnapshot) TakenAt() time.Time {
+	return s.takenAt
+}
+
+// TicketsByAttribute returns the tickets whose value of the numeric player attribute, aggregated over the players
+// like a matching rule does, is between minValue and maxValue included
+func (s *TicketSnapshot) TicketsByAttribute(attribute string, aggregation string, minValue float64, maxValue float64) []matchmaker.Ticket {
+	key := attributeKey{attribute: attribute, aggregation: aggregation}
+
+	s.lock.Lock()
+	index, ok := s.byAttribute[key]
+	if !ok {
+		positions := make([]int, len(s.tickets))
+		for i := range positions {
+			positions[i] = i
+		}
+		index = newSortedIndex(positions, func(i int) float64 { return ticketAttributeValue(s.tickets[i], attribute, aggregation) })
+		s.byAttribute[key] = index
+	}
+	s.lock.Unlock()
+
+	return s.ticketsAt(index.between(minValue, maxValue))
+}
+
+// TicketsByRegionLatency returns the tickets with a latency to the region of at most maxLatencyMs, or with any
+// latency to it when maxLatencyMs is 0. Tickets without latencies can play in any region and are always returned.
+func (s *TicketSnapshot) TicketsByRegionLatency(region string, maxLatencyMs int64) []matchmaker.Ticket {
+	if maxLatencyMs == 0 {
+		maxLatencyMs = math.MaxInt64
+	}
+
+	positions := append(s.byRegion[region].between(math.MinInt64, maxLatencyMs), s.anyRegion...)
+	slices.Sort(positions)
+
+	return s.ticketsAt(positions)
+}
+
+// TicketsByPartySize returns the tickets with between minPlayers and maxPlayers players included, or with at least
+// minPlayers players when maxPlayers is 0
+func (s *TicketSnapshot) TicketsByPartySize(minPlayers int, maxPlayers int) []matchmaker.Ticket {
+	if maxPlayers == 0 {
+		maxPlayers = math.MaxInt
+	}
+
+	return s.ticketsAt(s.byPartySize.between(minPlayers, maxPlayers))
+}
+
+// TicketsByAge returns the tickets that have been waiting between minAge and maxAge included, or at least minAge
+// when maxAge is 0. A ticket without a creation time is considered new.
+func (s *TicketSnapshot) TicketsByAge(minAge time.Duration, maxAge time.Duration) []matchmaker.Ticket {
+	if maxAge == 0 {
+		maxAge = math.MaxInt64
+	}
+
+	return s.ticketsAt(s.byAge.between(minAge, maxAge))
+}
+
+func (s *TicketSnapshot) ticketsAt(positions []int) []matchmaker.Ticket {
+	tickets := make([]matchmaker.Ticket, 0, len(positions))
+	for _, i := range positions {
+		tickets = append(tickets, s.tickets[i])
+	}
+
+	return tickets
+}
+
+// sortedIndex holds ticket positions sorted by a key, to find the tickets with a key within a range
+type sortedIndex[K cmp.Ordered] struct {
+	positions []int
+	keys      []K
+}
+
+func newSortedIndex[K cmp.Ordered](positions []int, key func(int) K) sortedIndex[K] {
+	keys := make(map[int]K, len(positions))
+	for _, i := range positions {
+		keys[i] = key(i)
+	}
+
+	index := sortedIndex[K]{positions: slices.Clone(positions)}
+	slices.SortStableFunc(index.positions, func(a, b int) int { return cmp.Compare(keys[a], keys[b]) })
+	index.keys = make([]K, len(index.positions))
+	for n, i := range index.positions {
+		index.keys[n] = keys[i]
+	}
+
+	return index
+}
+
+// between returns the positions, in ascending order, of the tickets with a key between minKey and maxKey included
+func (i sortedIndex[K]) between(minKey K, maxKey K) []int {
+	if minKey > maxKey {
+		return nil
+	}
+
+	from := sort.Search(len(i.keys), func(n int) bool { return i.keys[n] >= minKey })
+	to := sort.Search(len(i.keys), func(n int) bool { return i.keys[n] > maxKey })
+
+	positions := slices.Clone(i.positions[from:to])
+	slices.Sort(positions)
+
+	return positions
+}
+
+// bufferedChannel returns a closed channel holding the items
+func bufferedChannel[T any](items []T) chan T {
+	out := make(chan T, len(items))
+	for _, item := range items {
+		out <- item
+	}
+	close(out)
+
+	return out
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+	"matchmaking-function-grpc-plugin-server-go/pkg/playerdata"
+)
+
+const validationErrorDomain = "matchmaking-function"
+
+// Reasons reported in the errdetails.ErrorInfo of a rejected ticket
+const (
+	ReasonNoPlayers             = "NO_PLAYERS"
+	ReasonPartyTooLarge         = "PARTY_TOO_LARGE"
+	ReasonNoRegionWithinLatency = "NO_REGION_WITHIN_LATENCY"
+	ReasonMissingAttribute      = "MISSING_ATTRIBUTE"
+)
+
+// invalidTicketError returns an InvalidArgument status error carrying the reason and metadata as errdetails.ErrorInfo
+func invalidTicketError(reason string, message string, metadata map[string]string) error {
+	st := status.New(codes.InvalidArgument, message)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   validationErrorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// validateTicket checks the ticket against the rule and returns the error explaining the first failed check
+func validateTicket(ticket matchmaker.Ticket, rule GameRules) error {
+	if len(ticket.Players) == 0 {
+		return invalidTicketError(ReasonNoPlayers, "ticket has no players", nil)
+	}
+
+	if _, _, _, maxPlayers := rule.teamBounds(); len(ticket.Players) > maxPlayers {
+		return invalidTicketError(ReasonPartyTooLarge, "ticket has more players than a team can hold", map[string]string{
+			"players":        strconv.Itoa(len(ticket.Players)),
+			"maxTeamPlayers": strconv.Itoa(maxPlayers),
+		})
+	}
+
+	// the ticket is only rejected when no region is within the ceiling even once the relaxation rules loosened it
+	ceiling := rule.regionLatencyCeiling()
+	if regions := acceptableRegions(ticket, ceiling); regions != nil && len(regions) == 0 {
+		return invalidTicketError(ReasonNoRegionWithinLatency, "ticket has no region within the latency limit", map[string]string{
+			"regionLatencyMaxMs": strconv.Itoa(ceiling),
+		})
+	}
+
+	for _, player := range ticket.Players {
+		for _, attribute := range rule.TicketValidation.RequiredAttributes {
+			if _, ok := player.Attributes[attribute]; !ok {
+				return invalidTicketError(ReasonMissingAttribute, "player is missing a required attribute", map[string]string{
+					"playerID":  playerdata.IDToString(player.PlayerID),
+					"attribute": attribute,
+				})
+			}
+		}
+	}
+
+	return nil
+}
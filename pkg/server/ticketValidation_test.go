@@ -0,0 +1,171 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+func TestValidateTicket(t *testing.T) {
+	rule := GameRules{
+		AllianceRule:       AllianceRule{MinNumber: 2, MaxNumber: 2, PlayerMinNumber: 1, PlayerMaxNumber: 2},
+		RegionLatencyMaxMs: 100,
+		TicketValidation:   TicketValidationRule{RequiredAttributes: []string{"mmr"}},
+	}
+
+	tests := []struct {
+		name   string
+		ticket matchmaker.Ticket
+		reason string // empty when the ticket is valid
+	}{
+		{
+			name:   "valid ticket",
+			ticket: testTicket("a", time.Now(), 10, 20),
+		},
+		{
+			name:   "no players",
+			ticket: matchmaker.Ticket{TicketID: "a"},
+			reason: ReasonNoPlayers,
+		},
+		{
+			name:   "party larger than a team",
+			ticket: testTicket("a", time.Now(), 10, 20, 30),
+			reason: ReasonPartyTooLarge,
+		},
+		{
+			name:   "region within the latency ceiling",
+			ticket: latencyTicket("a", map[string]int64{"us": 150, "eu": 50}),
+		},
+		{
+			name:   "no region within the latency ceiling",
+			ticket: latencyTicket("a", map[string]int64{"us": 150, "eu": 200}),
+			reason: ReasonNoRegionWithinLatency,
+		},
+		{
+			name: "missing attribute",
+			ticket: func() matchmaker.Ticket {
+				ticket := testTicket("a", time.Now(), 10, 20)
+				delete(ticket.Players[1].Attributes, "mmr")
+
+				return ticket
+			}(),
+			reason: ReasonMissingAttribute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := MatchMaker{}.ValidateTicket(testScope(), tt.ticket, rule)
+			if valid != (tt.reason == "") {
+				t.Errorf("expected the ticket valid %v, got %v", tt.reason == "", valid)
+			}
+			if tt.reason == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Errorf("expected an InvalidArgument error, got %v", err)
+			}
+			var reason string
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.ErrorInfo); ok {
+					reason = info.Reason
+				}
+			}
+			if reason != tt.reason {
+				t.Errorf("expected the reason %q, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestValidateTicketRelaxedLatency(t *testing.T) {
+	tests := []struct {
+		name       string
+		relaxation RelaxationRule
+		latency    int64
+		valid      bool
+	}{
+		{
+			name:       "within the last step",
+			relaxation: RelaxationRule{Target: RelaxRegionLatencyMaxMs, Steps: []RelaxationStep{{AfterSec: 10, Value: 150}, {AfterSec: 30, Value: 300}}},
+			latency:    200,
+			valid:      true,
+		},
+		{
+			name:       "above the last step",
+			relaxation: RelaxationRule{Target: RelaxRegionLatencyMaxMs, Steps: []RelaxationStep{{AfterSec: 10, Value: 150}, {AfterSec: 30, Value: 300}}},
+			latency:    400,
+		},
+		{
+			name:       "within the linear limit",
+			relaxation: RelaxationRule{Target: RelaxRegionLatencyMaxMs, Schedule: ScheduleLinear, RatePerSec: 10, Limit: 300},
+			latency:    200,
+			valid:      true,
+		},
+		{
+			name:       "linear without a limit",
+			relaxation: RelaxationRule{Target: RelaxRegionLatencyMaxMs, Schedule: ScheduleLinear, RatePerSec: 10},
+			latency:    1000,
+			valid:      true,
+		},
+		{
+			name:       "lowered ceiling",
+			relaxation: RelaxationRule{Target: RelaxRegionLatencyMaxMs, Schedule: ScheduleLinear, RatePerSec: -10, Limit: 50},
+			latency:    200,
+		},
+		{
+			name:       "other target",
+			relaxation: RelaxationRule{Target: RelaxMinTeamNumber, Steps: []RelaxationStep{{AfterSec: 10, Value: 1}}},
+			latency:    200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := GameRules{RegionLatencyMaxMs: 100, Relaxation: []RelaxationRule{tt.relaxation}}
+
+			valid, err := MatchMaker{}.ValidateTicket(testScope(), latencyTicket("a", map[string]int64{"us": tt.latency}), rule)
+			if valid != tt.valid {
+				t.Errorf("expected the ticket valid %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
+
+func TestRulesFromJSONTicketValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "no ticket validation", rules: `{}`},
+		{name: "required attributes", rules: `{"ticket_validation": {"required_attributes": ["mmr", "role"]}}`},
+		{name: "required attributes not a list", rules: `{"ticket_validation": {"required_attributes": "mmr"}}`, wantErr: true},
+		{name: "not json", rules: `ticket_validation`, wantErr: true},
+		{name: "team size bounds reversed", rules: `{"alliance": {"player_min_number": 3, "player_max_number": 2}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MatchMaker{}.RulesFromJSON(testScope(), tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2026 AccelByte Inc. All Rights Reserved.
+// This is licensed software from AccelByte Inc, for limitations
+// and restrictions contact your company contract manager.
+
+package server
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"matchmaking-function-grpc-plugin-server-go/pkg/common"
+	"matchmaking-function-grpc-plugin-server-go/pkg/matchmaker"
+)
+
+// AdaptMatchLogic returns the TypedMatchLogic as a MatchLogic. Rules that are not an R are rejected with an
+// InvalidArgument error by CheckRules, or, where the MatchLogic method has no error to return, logged and answered
+// with no result.
+func AdaptMatchLogic[R any](logic TypedMatchLogic[R]) MatchLogic {
+	return typedMatchLogicAdapter[R]{logic: logic}
+}
+
+type typedMatchLogicAdapter[R any] struct {
+	logic TypedMatchLogic[R]
+}
+
+func (a typedMatchLogicAdapter[R]) rules(matchRules interface{}) (R, error) {
+	rules, ok := matchRules.(R)
+	if !ok {
+		var expected R
+
+		return expected, status.Errorf(codes.InvalidArgument, "unexpected rules type %T, expected %T", matchRules, expected)
+	}
+
+	return rules, nil
+}
+
+// CheckRules returns an InvalidArgument error when the rules are not an R
+func (a typedMatchLogicAdapter[R]) CheckRules(matchRules interface{}) error {
+	_, err := a.rules(matchRules)
+
+	return err
+}
+
+func (a typedMatchLogicAdapter[R]) RulesFromJSON(scope *common.Scope, json string) (interface{}, error) {
+	return a.logic.RulesFromJSON(scope, json)
+}
+
+func (a typedMatchLogicAdapter[R]) GetStatCodes(scope *common.Scope, matchRules interface{}) []string {
+	rules, err := a.rules(matchRules)
+	if err != nil {
+		scope.Log.Error("could not get stat codes", "error", err)
+
+		return nil
+	}
+
+	return a.logic.GetStatCodes(scope, rules)
+}
+
+func (a typedMatchLogicAdapter[R]) ValidateTicket(scope *common.Scope, matchTicket matchmaker.Ticket, matchRules interface{}) (bool, error) {
+	rules, err := a.rules(matchRules)
+	if err != nil {
+		return false, err
+	}
+
+	return a.logic.ValidateTicket(scope, matchTicket, rules)
+}
+
+// EnrichTicket is given the rules request message rather than decoded rules, so the rules are decoded here. A
+// request without rules enriches the ticket with the zero rules.
+func (a typedMatchLogicAdapter[R]) EnrichTicket(scope *common.Scope, matchTicket matchmaker.Ticket, ruleSet interface{}) (matchmaker.Ticket, error) {
+	var rules R
+	switch ruleSet := ruleSet.(type) {
+	case R:
+		rules = ruleSet
+	case interface{ GetJson() string }:
+		if json := ruleSet.GetJson(); json != "" {
+			decoded, err := a.logic.RulesFromJSON(scope, json)
+			if err != nil {
+				return matchTicket, err
+			}
+			rules = decoded
+		}
+	case nil:
+	default:
+		return matchTicket, status.Errorf(codes.InvalidArgument, "unexpected rules type %T, expected %T", ruleSet, rules)
+	}
+
+	return a.logic.EnrichTicket(scope, matchTicket, rules)
+}
+
+func (a typedMatchLogicAdapter[R]) MakeMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.Match {
+	rules, err := a.rules(matchRules)
+	if err != nil {
+		scope.Log.Error("could not make matches", "error", err)
+
+		return nil
+	}
+
+	return a.logic.MakeMatches(scope, ticketProvider, rules)
+}
+
+func (a typedMatchLogicAdapter[R]) BackfillMatches(scope *common.Scope, ticketProvider TicketProvider, matchRules interface{}) <-chan matchmaker.BackfillProposal {
+	rules, err := a.rules(matchRules)
+	if err != nil {
+		scope.Log.Error("could not run backfill", "error", err)
+
+		return nil
+	}
+
+	return a.logic.BackfillMatches(scope, ticketProvider, rules)
+}